@@ -2,7 +2,14 @@ use std::{collections::HashMap, sync::Arc};
 
 use xrouter_core::{CoreError, ExecutionEngine, ModelDescriptor, synthesize_model_id};
 
-use crate::{config, startup::app_builder::AppBuilder};
+use crate::{
+    config::{self, AssistantPrefillPolicy, EndpointClass, ModelDeprecation},
+    http::{
+        circuit_breaker::CircuitBreakers, cors::CorsPolicy, dry_run::DryRunLimiter,
+        endpoint_switch::EndpointSwitches, idempotency::IdempotencyStore,
+    },
+    startup::app_builder::AppBuilder,
+};
 
 #[derive(Clone)]
 pub struct AppState {
@@ -11,6 +18,14 @@ pub struct AppState {
     pub(crate) default_provider: String,
     pub(crate) models: Vec<ModelDescriptor>,
     pub(crate) engines: HashMap<String, Arc<ExecutionEngine>>,
+    pub(crate) dry_run_limiter: Arc<DryRunLimiter>,
+    pub(crate) assistant_prefill: AssistantPrefillPolicy,
+    pub(crate) cors: Arc<CorsPolicy>,
+    pub(crate) idempotency: Arc<IdempotencyStore>,
+    pub(crate) circuit_breakers: Arc<CircuitBreakers>,
+    pub(crate) model_deprecations: Arc<HashMap<String, ModelDeprecation>>,
+    pub(crate) endpoint_switches: Arc<EndpointSwitches>,
+    pub(crate) max_request_body_bytes: usize,
 }
 
 impl AppState {
@@ -37,7 +52,76 @@ impl AppState {
                 .unwrap_or_else(|| "openrouter".to_string())
         };
 
-        Self { openai_compatible_api, byok_enabled, default_provider, models, engines }
+        Self {
+            openai_compatible_api,
+            byok_enabled,
+            default_provider,
+            models,
+            engines,
+            dry_run_limiter: Arc::new(DryRunLimiter::new(
+                config::DEFAULT_DRY_RUN_MAX_PER_MINUTE,
+                config::DEFAULT_DRY_RUN_GLOBAL_MAX_PER_MINUTE,
+            )),
+            assistant_prefill: AssistantPrefillPolicy::Emulate,
+            cors: Arc::new(CorsPolicy::same_origin_only()),
+            idempotency: Arc::new(IdempotencyStore::new(
+                config::DEFAULT_IDEMPOTENCY_TTL_SECONDS,
+                config::DEFAULT_MAX_UPSTREAM_RESPONSE_BYTES,
+            )),
+            circuit_breakers: Arc::new(CircuitBreakers::new(
+                0,
+                config::DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS,
+            )),
+            model_deprecations: Arc::new(HashMap::new()),
+            endpoint_switches: Arc::new(EndpointSwitches::new(HashMap::new())),
+            max_request_body_bytes: config::DEFAULT_MAX_REQUEST_BODY_BYTES,
+        }
+    }
+
+    pub(crate) fn with_dry_run_limits(mut self, max_per_caller: usize, max_total: usize) -> Self {
+        self.dry_run_limiter = Arc::new(DryRunLimiter::new(max_per_caller, max_total));
+        self
+    }
+
+    pub(crate) fn with_max_request_body_bytes(mut self, max_bytes: usize) -> Self {
+        self.max_request_body_bytes = max_bytes;
+        self
+    }
+
+    pub(crate) fn with_assistant_prefill(mut self, policy: AssistantPrefillPolicy) -> Self {
+        self.assistant_prefill = policy;
+        self
+    }
+
+    pub(crate) fn with_idempotency(mut self, ttl_seconds: u64, max_response_bytes: usize) -> Self {
+        self.idempotency = Arc::new(IdempotencyStore::new(ttl_seconds, max_response_bytes));
+        self
+    }
+
+    pub(crate) fn with_circuit_breaker(mut self, threshold: u32, open_seconds: u64) -> Self {
+        self.circuit_breakers = Arc::new(CircuitBreakers::new(threshold, open_seconds));
+        self
+    }
+
+    pub(crate) fn with_cors(mut self, cors: &config::CorsConfig) -> Self {
+        self.cors = Arc::new(CorsPolicy::from_config(cors));
+        self
+    }
+
+    pub(crate) fn with_model_deprecations(
+        mut self,
+        deprecations: &HashMap<String, ModelDeprecation>,
+    ) -> Self {
+        self.model_deprecations = Arc::new(deprecations.clone());
+        self
+    }
+
+    pub(crate) fn with_disabled_endpoints(
+        mut self,
+        disabled: &HashMap<EndpointClass, String>,
+    ) -> Self {
+        self.endpoint_switches = Arc::new(EndpointSwitches::new(disabled.clone()));
+        self
     }
 
     pub(crate) fn resolve_provider_key(&self, model: &str) -> String {
@@ -1,5 +1,9 @@
-use std::collections::HashMap;
+use std::collections::{BTreeMap, HashMap};
 use std::env;
+use std::net::SocketAddr;
+
+use axum::http::{HeaderName, HeaderValue};
+use serde::Deserialize;
 
 pub const DEFAULT_OPENROUTER_SUPPORTED_MODELS: &[&str] = &[
     "anthropic/claude-haiku-4.5",
@@ -47,12 +51,97 @@ pub const DEFAULT_OPENROUTER_SUPPORTED_MODELS: &[&str] = &[
 pub const DEFAULT_GIGACHAT_SUPPORTED_MODELS: &[&str] =
     &["gigachat/GigaChat-2", "gigachat/GigaChat-2-Max", "gigachat/GigaChat-2-Pro"];
 
+pub const DEFAULT_DRY_RUN_MAX_PER_MINUTE: usize = 30;
+pub const DEFAULT_DRY_RUN_GLOBAL_MAX_PER_MINUTE: usize = 300;
+pub const DEFAULT_IDEMPOTENCY_TTL_SECONDS: u64 = 300;
+pub const DEFAULT_MAX_REQUEST_BODY_BYTES: usize = 2 * 1024 * 1024;
+pub const DEFAULT_MAX_UPSTREAM_RESPONSE_BYTES: usize = 16 * 1024 * 1024;
+pub const DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS: u64 = 30;
+pub const PROVIDER_KEY_PLACEHOLDER: &str = "{{key}}";
+pub const DEFAULT_CORS_ALLOWED_HEADERS: &[&str] = &[
+    "authorization",
+    "content-type",
+    "http-referer",
+    "idempotency-key",
+    "x-allow-deprecated-models",
+    "x-dry-run",
+    "x-openrouter-categories",
+    "x-openrouter-title",
+    "x-title",
+];
+
+const FORBIDDEN_PROVIDER_HEADERS: &[&str] = &[
+    "connection",
+    "content-length",
+    "host",
+    "keep-alive",
+    "proxy-authenticate",
+    "proxy-authorization",
+    "proxy-connection",
+    "te",
+    "trailer",
+    "transfer-encoding",
+    "upgrade",
+];
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum AssistantPrefillPolicy {
+    Emulate,
+    Reject,
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, PartialOrd, Ord)]
+pub enum EndpointClass {
+    Chat,
+    Responses,
+}
+
+impl EndpointClass {
+    pub fn as_str(self) -> &'static str {
+        match self {
+            Self::Chat => "chat",
+            Self::Responses => "responses",
+        }
+    }
+
+    fn parse(value: &str) -> Option<Self> {
+        match value.trim().to_ascii_lowercase().as_str() {
+            "chat" => Some(Self::Chat),
+            "responses" => Some(Self::Responses),
+            _ => None,
+        }
+    }
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ModelDeprecation {
+    pub deprecated_at: String,
+    pub replacement: Option<String>,
+}
+
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+struct RawModelDeprecation {
+    deprecated_at: String,
+    replacement: Option<String>,
+}
+
+#[derive(Debug, Clone)]
+pub struct CorsConfig {
+    pub allowed_origins: Vec<String>,
+    pub allowed_headers: Vec<String>,
+    pub max_age_seconds: u64,
+    pub allow_credentials: bool,
+}
+
 #[derive(Debug, Clone)]
 pub struct ProviderConfig {
     pub enabled: bool,
     pub api_key: Option<String>,
     pub base_url: Option<String>,
     pub project: Option<String>,
+    pub headers: Vec<(String, String)>,
+    pub suppress_bearer: bool,
 }
 
 #[derive(Debug, Clone)]
@@ -63,12 +152,58 @@ pub struct AppConfig {
     pub byok_enabled: bool,
     pub provider_timeout_seconds: u64,
     pub provider_max_inflight: usize,
+    pub max_request_body_bytes: usize,
+    pub max_upstream_response_bytes: usize,
+    pub dry_run_max_per_minute: usize,
+    pub dry_run_global_max_per_minute: usize,
+    pub idempotency_ttl_seconds: u64,
+    pub circuit_breaker_threshold: u32,
+    pub circuit_breaker_open_seconds: u64,
+    pub assistant_prefill: AssistantPrefillPolicy,
+    pub cors: CorsConfig,
+    pub model_deprecations: HashMap<String, ModelDeprecation>,
+    pub disabled_endpoints: HashMap<EndpointClass, String>,
     pub gigachat_insecure_tls: bool,
     pub openrouter_supported_models: Vec<String>,
     pub gigachat_supported_models: Vec<String>,
     pub providers: HashMap<String, ProviderConfig>,
 }
 
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum ConfigIssueSeverity {
+    Fatal,
+    Warning,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ConfigIssue {
+    pub severity: ConfigIssueSeverity,
+    pub setting: String,
+    pub message: String,
+}
+
+impl ConfigIssue {
+    fn fatal(setting: impl Into<String>, message: impl Into<String>) -> Self {
+        Self {
+            severity: ConfigIssueSeverity::Fatal,
+            setting: setting.into(),
+            message: message.into(),
+        }
+    }
+
+    fn warning(setting: impl Into<String>, message: impl Into<String>) -> Self {
+        Self {
+            severity: ConfigIssueSeverity::Warning,
+            setting: setting.into(),
+            message: message.into(),
+        }
+    }
+
+    pub fn is_fatal(&self) -> bool {
+        self.severity == ConfigIssueSeverity::Fatal
+    }
+}
+
 #[derive(Debug, thiserror::Error)]
 pub enum ConfigError {
     #[error("invalid XR_PORT value: {0}")]
@@ -81,6 +216,38 @@ pub enum ConfigError {
     InvalidProviderConnectTimeout(String),
     #[error("invalid XR_PROVIDER_MAX_INFLIGHT value: {0}")]
     InvalidProviderMaxInflight(String),
+    #[error("invalid XR_MAX_REQUEST_BODY_BYTES value: {0}")]
+    InvalidMaxRequestBodyBytes(String),
+    #[error("invalid XR_MAX_UPSTREAM_RESPONSE_BYTES value: {0}")]
+    InvalidMaxUpstreamResponseBytes(String),
+    #[error("invalid XR_DRY_RUN_MAX_PER_MINUTE value: {0}")]
+    InvalidDryRunMaxPerMinute(String),
+    #[error("invalid XR_DRY_RUN_GLOBAL_MAX_PER_MINUTE value: {0}")]
+    InvalidDryRunGlobalMaxPerMinute(String),
+    #[error("invalid XR_IDEMPOTENCY_TTL value: {0}")]
+    InvalidIdempotencyTtl(String),
+    #[error("invalid XR_CIRCUIT_BREAKER_THRESHOLD value: {0}")]
+    InvalidCircuitBreakerThreshold(String),
+    #[error("invalid XR_CIRCUIT_BREAKER_OPEN_SECONDS value: {0}")]
+    InvalidCircuitBreakerOpenSeconds(String),
+    #[error("invalid XR_ASSISTANT_PREFILL value: {0}")]
+    InvalidAssistantPrefill(String),
+    #[error("invalid XR_CORS_ALLOWED_ORIGINS entry: {0}")]
+    InvalidCorsOrigin(String),
+    #[error("invalid XR_CORS_ALLOWED_HEADERS entry: {0}")]
+    InvalidCorsAllowedHeader(String),
+    #[error("invalid XR_CORS_MAX_AGE value: {0}")]
+    InvalidCorsMaxAge(String),
+    #[error("invalid XR_CORS_ALLOW_CREDENTIALS value: {0}")]
+    InvalidCorsAllowCredentialsBool(String),
+    #[error("invalid XR_MODEL_DEPRECATIONS value: {0}")]
+    InvalidModelDeprecations(String),
+    #[error("invalid XR_DISABLED_ENDPOINTS value: {0}")]
+    InvalidDisabledEndpoints(String),
+    #[error("invalid {0}_HEADERS value: {1}")]
+    InvalidProviderHeaders(String, String),
+    #[error("{0}_SUPPRESS_BEARER is not supported for {1} provider")]
+    UnsupportedSuppressBearer(String, String),
 }
 
 impl AppConfig {
@@ -108,6 +275,65 @@ impl AppConfig {
             env::var("XR_PROVIDER_MAX_INFLIGHT").unwrap_or_else(|_| "100".to_string());
         let provider_max_inflight = parse_positive_usize(&provider_max_inflight_raw)
             .ok_or(ConfigError::InvalidProviderMaxInflight(provider_max_inflight_raw))?;
+        let max_request_body_bytes_raw = env::var("XR_MAX_REQUEST_BODY_BYTES")
+            .unwrap_or_else(|_| DEFAULT_MAX_REQUEST_BODY_BYTES.to_string());
+        let max_request_body_bytes = parse_positive_usize(&max_request_body_bytes_raw)
+            .ok_or(ConfigError::InvalidMaxRequestBodyBytes(max_request_body_bytes_raw))?;
+        let max_upstream_response_bytes_raw = env::var("XR_MAX_UPSTREAM_RESPONSE_BYTES")
+            .unwrap_or_else(|_| DEFAULT_MAX_UPSTREAM_RESPONSE_BYTES.to_string());
+        let max_upstream_response_bytes = parse_positive_usize(&max_upstream_response_bytes_raw)
+            .ok_or(ConfigError::InvalidMaxUpstreamResponseBytes(max_upstream_response_bytes_raw))?;
+        let dry_run_max_per_minute_raw = env::var("XR_DRY_RUN_MAX_PER_MINUTE")
+            .unwrap_or_else(|_| DEFAULT_DRY_RUN_MAX_PER_MINUTE.to_string());
+        let dry_run_max_per_minute = parse_positive_usize(&dry_run_max_per_minute_raw)
+            .ok_or(ConfigError::InvalidDryRunMaxPerMinute(dry_run_max_per_minute_raw))?;
+        let dry_run_global_max_per_minute_raw = env::var("XR_DRY_RUN_GLOBAL_MAX_PER_MINUTE")
+            .unwrap_or_else(|_| DEFAULT_DRY_RUN_GLOBAL_MAX_PER_MINUTE.to_string());
+        let dry_run_global_max_per_minute = parse_positive_usize(
+            &dry_run_global_max_per_minute_raw,
+        )
+        .ok_or(ConfigError::InvalidDryRunGlobalMaxPerMinute(dry_run_global_max_per_minute_raw))?;
+        let idempotency_ttl_raw = env::var("XR_IDEMPOTENCY_TTL")
+            .unwrap_or_else(|_| DEFAULT_IDEMPOTENCY_TTL_SECONDS.to_string());
+        let idempotency_ttl_seconds = idempotency_ttl_raw
+            .trim()
+            .parse::<u64>()
+            .map_err(|_| ConfigError::InvalidIdempotencyTtl(idempotency_ttl_raw.clone()))?;
+        let circuit_breaker_threshold_raw =
+            env::var("XR_CIRCUIT_BREAKER_THRESHOLD").unwrap_or_else(|_| "0".to_string());
+        let circuit_breaker_threshold =
+            circuit_breaker_threshold_raw.trim().parse::<u32>().map_err(|_| {
+                ConfigError::InvalidCircuitBreakerThreshold(circuit_breaker_threshold_raw.clone())
+            })?;
+        let circuit_breaker_open_seconds_raw = env::var("XR_CIRCUIT_BREAKER_OPEN_SECONDS")
+            .unwrap_or_else(|_| DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS.to_string());
+        let circuit_breaker_open_seconds = circuit_breaker_open_seconds_raw
+            .trim()
+            .parse::<u64>()
+            .ok()
+            .filter(|seconds| *seconds > 0)
+            .ok_or(ConfigError::InvalidCircuitBreakerOpenSeconds(
+                circuit_breaker_open_seconds_raw,
+            ))?;
+        let assistant_prefill_raw =
+            env::var("XR_ASSISTANT_PREFILL").unwrap_or_else(|_| "emulate".to_string());
+        let assistant_prefill = parse_assistant_prefill_policy(&assistant_prefill_raw)
+            .ok_or(ConfigError::InvalidAssistantPrefill(assistant_prefill_raw))?;
+        let cors = cors_from_env()?;
+        let model_deprecations =
+            match env::var("XR_MODEL_DEPRECATIONS").ok().filter(|v| !v.trim().is_empty()) {
+                Some(raw) => {
+                    parse_model_deprecations(&raw).map_err(ConfigError::InvalidModelDeprecations)?
+                }
+                None => HashMap::new(),
+            };
+        let disabled_endpoints =
+            match env::var("XR_DISABLED_ENDPOINTS").ok().filter(|v| !v.trim().is_empty()) {
+                Some(raw) => {
+                    parse_disabled_endpoints(&raw).map_err(ConfigError::InvalidDisabledEndpoints)?
+                }
+                None => HashMap::new(),
+            };
         let gigachat_insecure_tls =
             env::var("GIGACHAT_INSECURE_TLS").ok().and_then(|v| parse_bool(&v)).unwrap_or(false);
         let openrouter_supported_models = parse_string_list_env(
@@ -127,7 +353,7 @@ impl AppConfig {
             provider_from_env("xrouter", "XROUTER"),
         ]
         .into_iter()
-        .collect::<HashMap<_, _>>();
+        .collect::<Result<HashMap<_, _>, _>>()?;
 
         Ok(Self {
             host,
@@ -136,6 +362,17 @@ impl AppConfig {
             byok_enabled,
             provider_timeout_seconds,
             provider_max_inflight,
+            max_request_body_bytes,
+            max_upstream_response_bytes,
+            dry_run_max_per_minute,
+            dry_run_global_max_per_minute,
+            idempotency_ttl_seconds,
+            circuit_breaker_threshold,
+            circuit_breaker_open_seconds,
+            assistant_prefill,
+            cors,
+            model_deprecations,
+            disabled_endpoints,
             gigachat_insecure_tls,
             openrouter_supported_models,
             gigachat_supported_models,
@@ -151,6 +388,25 @@ impl AppConfig {
             byok_enabled: false,
             provider_timeout_seconds: 15,
             provider_max_inflight: 100,
+            max_request_body_bytes: DEFAULT_MAX_REQUEST_BODY_BYTES,
+            max_upstream_response_bytes: DEFAULT_MAX_UPSTREAM_RESPONSE_BYTES,
+            dry_run_max_per_minute: DEFAULT_DRY_RUN_MAX_PER_MINUTE,
+            dry_run_global_max_per_minute: DEFAULT_DRY_RUN_GLOBAL_MAX_PER_MINUTE,
+            idempotency_ttl_seconds: DEFAULT_IDEMPOTENCY_TTL_SECONDS,
+            circuit_breaker_threshold: 0,
+            circuit_breaker_open_seconds: DEFAULT_CIRCUIT_BREAKER_OPEN_SECONDS,
+            assistant_prefill: AssistantPrefillPolicy::Emulate,
+            cors: CorsConfig {
+                allowed_origins: Vec::new(),
+                allowed_headers: DEFAULT_CORS_ALLOWED_HEADERS
+                    .iter()
+                    .map(|header| (*header).to_string())
+                    .collect(),
+                max_age_seconds: 600,
+                allow_credentials: false,
+            },
+            model_deprecations: HashMap::new(),
+            disabled_endpoints: HashMap::new(),
             gigachat_insecure_tls: false,
             openrouter_supported_models: DEFAULT_OPENROUTER_SUPPORTED_MODELS
                 .iter()
@@ -161,48 +417,152 @@ impl AppConfig {
                 .map(|model| (*model).to_string())
                 .collect(),
             providers: [
-                (
-                    "openrouter".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "deepseek".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "gigachat".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "yandex".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "ollama".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "zai".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
-                (
-                    "xrouter".to_string(),
-                    ProviderConfig { enabled: true, api_key: None, base_url: None, project: None },
-                ),
+                ("openrouter".to_string(), ProviderConfig::enabled_for_tests()),
+                ("deepseek".to_string(), ProviderConfig::enabled_for_tests()),
+                ("gigachat".to_string(), ProviderConfig::enabled_for_tests()),
+                ("yandex".to_string(), ProviderConfig::enabled_for_tests()),
+                ("ollama".to_string(), ProviderConfig::enabled_for_tests()),
+                ("zai".to_string(), ProviderConfig::enabled_for_tests()),
+                ("xrouter".to_string(), ProviderConfig::enabled_for_tests()),
             ]
             .into_iter()
             .collect(),
         }
     }
+
+    pub fn bind_addr(&self) -> Option<SocketAddr> {
+        format!("{}:{}", self.host, self.port).parse().ok()
+    }
+
+    /// Checks invariants that individual settings cannot express. Values that fail to parse are
+    /// already rejected by `from_env`; this reports settings that parse but would misbehave.
+    pub fn validate(&self) -> Vec<ConfigIssue> {
+        let mut issues = Vec::new();
+
+        if self.bind_addr().is_none() {
+            issues.push(ConfigIssue::fatal(
+                "XR_HOST",
+                format!("{} is not an IP address the server can bind to", self.host),
+            ));
+        }
+
+        let mut enabled =
+            self.providers.iter().filter(|(_, provider)| provider.enabled).collect::<Vec<_>>();
+        enabled.sort_by(|left, right| left.0.cmp(right.0));
+        if enabled.is_empty() {
+            issues.push(ConfigIssue::fatal("<PROVIDER>_ENABLED", "no providers are enabled"));
+        }
+        for (name, provider) in enabled {
+            validate_provider(name, provider, self.byok_enabled, &mut issues);
+        }
+
+        if self.gigachat_insecure_tls && self.providers.get("gigachat").is_some_and(|p| p.enabled) {
+            issues.push(ConfigIssue::warning(
+                "GIGACHAT_INSECURE_TLS",
+                "TLS certificate verification is disabled for gigachat",
+            ));
+        }
+        if self.disabled_endpoints.len() == 2 {
+            issues.push(ConfigIssue::warning(
+                "XR_DISABLED_ENDPOINTS",
+                "every inference endpoint is disabled",
+            ));
+        }
+
+        let mut deprecations = self.model_deprecations.iter().collect::<Vec<_>>();
+        deprecations.sort_by(|left, right| left.0.cmp(right.0));
+        for (model, deprecation) in deprecations {
+            if let Some(replacement) = &deprecation.replacement
+                && self.model_deprecations.contains_key(replacement)
+            {
+                issues.push(ConfigIssue::warning(
+                    "XR_MODEL_DEPRECATIONS",
+                    format!("replacement {replacement} for {model} is deprecated as well"),
+                ));
+            }
+        }
+
+        issues
+    }
+}
+
+impl ProviderConfig {
+    fn enabled_for_tests() -> Self {
+        Self {
+            enabled: true,
+            api_key: None,
+            base_url: None,
+            project: None,
+            headers: Vec::new(),
+            suppress_bearer: false,
+        }
+    }
 }
 
-fn provider_from_env(name: &str, prefix: &str) -> (String, ProviderConfig) {
+fn validate_provider(
+    name: &str,
+    provider: &ProviderConfig,
+    byok_enabled: bool,
+    issues: &mut Vec<ConfigIssue>,
+) {
+    let prefix = name.to_ascii_uppercase();
+    match provider.base_url.as_deref() {
+        None => issues.push(ConfigIssue::warning(
+            format!("{prefix}_BASE_URL"),
+            format!("{name} is enabled but has no base URL, its requests will fail"),
+        )),
+        Some(base_url) if !base_url.starts_with("http://") && !base_url.starts_with("https://") => {
+            issues.push(ConfigIssue::fatal(
+                format!("{prefix}_BASE_URL"),
+                format!("{base_url} must start with http:// or https://"),
+            ));
+        }
+        Some(_) => {}
+    }
+
+    let key_setting = if name == "gigachat" {
+        "GIGACHAT_CREDENTIALS".to_string()
+    } else {
+        format!("{prefix}_API_KEY")
+    };
+    if byok_enabled {
+        if name == "yandex" {
+            issues.push(ConfigIssue::warning(
+                "XR_BYOK_ENABLED",
+                "yandex is enabled but rejects BYOK requests with 400",
+            ));
+        }
+    } else if provider.api_key.is_none() && !provider.suppress_bearer && name != "ollama" {
+        issues.push(ConfigIssue::warning(
+            key_setting.as_str(),
+            format!("{name} is enabled without credentials, upstream calls will be unauthorized"),
+        ));
+    }
+    if provider.api_key.is_none()
+        && provider.headers.iter().any(|(_, value)| value.contains(PROVIDER_KEY_PLACEHOLDER))
+    {
+        issues.push(ConfigIssue::warning(
+            format!("{prefix}_HEADERS"),
+            format!("headers using {PROVIDER_KEY_PLACEHOLDER} are skipped without {key_setting}"),
+        ));
+    }
+    if name == "yandex" && provider.project.is_none() {
+        issues.push(ConfigIssue::warning(
+            "YANDEX_FOLDER_ID",
+            "yandex is enabled without a folder id, model URIs cannot be built",
+        ));
+    }
+}
+
+fn provider_from_env(name: &str, prefix: &str) -> Result<(String, ProviderConfig), ConfigError> {
     let enabled_var = format!("{prefix}_ENABLED");
     let enabled = env::var(enabled_var).ok().and_then(|v| parse_bool(&v)).unwrap_or(true);
 
     let api_key_var = format!("{prefix}_API_KEY");
     let base_url_var = format!("{prefix}_BASE_URL");
     let project_var = format!("{prefix}_PROJECT");
+    let headers_var = format!("{prefix}_HEADERS");
+    let suppress_bearer_var = format!("{prefix}_SUPPRESS_BEARER");
 
     let api_key = if name == "gigachat" {
         env::var("GIGACHAT_CREDENTIALS").ok().filter(|v| !v.trim().is_empty())
@@ -222,7 +582,170 @@ fn provider_from_env(name: &str, prefix: &str) -> (String, ProviderConfig) {
         env::var(project_var).ok().filter(|v| !v.trim().is_empty())
     };
 
-    (name.to_string(), ProviderConfig { enabled, api_key, base_url, project })
+    let headers = match env::var(headers_var).ok().filter(|v| !v.trim().is_empty()) {
+        Some(raw) => parse_provider_headers(&raw)
+            .map_err(|reason| ConfigError::InvalidProviderHeaders(prefix.to_string(), reason))?,
+        None => Vec::new(),
+    };
+    let suppress_bearer =
+        env::var(suppress_bearer_var).ok().and_then(|v| parse_bool(&v)).unwrap_or(false);
+    if suppress_bearer && !supports_suppress_bearer(name) {
+        return Err(ConfigError::UnsupportedSuppressBearer(prefix.to_string(), name.to_string()));
+    }
+
+    Ok((
+        name.to_string(),
+        ProviderConfig { enabled, api_key, base_url, project, headers, suppress_bearer },
+    ))
+}
+
+// gigachat sends an OAuth token obtained from its credentials and yandex sends its API key or IAM
+// token; without their `Authorization` header every request fails authentication.
+fn supports_suppress_bearer(provider: &str) -> bool {
+    !matches!(provider, "gigachat" | "yandex")
+}
+
+fn parse_provider_headers(raw: &str) -> Result<Vec<(String, String)>, String> {
+    let parsed = serde_json::from_str::<BTreeMap<String, String>>(raw.trim())
+        .map_err(|_| "expected a JSON object of string header values".to_string())?;
+    let mut headers = Vec::with_capacity(parsed.len());
+    for (name, value) in parsed {
+        let name = name.trim().to_ascii_lowercase();
+        if HeaderName::from_bytes(name.as_bytes()).is_err() {
+            return Err(format!("invalid header name {name}"));
+        }
+        if FORBIDDEN_PROVIDER_HEADERS.contains(&name.as_str()) {
+            return Err(format!("header {name} is not allowed"));
+        }
+        if HeaderValue::from_str(&value).is_err() {
+            return Err(format!("invalid value for header {name}"));
+        }
+        headers.push((name, value));
+    }
+    Ok(headers)
+}
+
+fn parse_model_deprecations(raw: &str) -> Result<HashMap<String, ModelDeprecation>, String> {
+    let parsed = serde_json::from_str::<BTreeMap<String, RawModelDeprecation>>(raw.trim())
+        .map_err(|_| {
+            "expected a JSON object of {\"deprecated_at\", \"replacement\"} entries".to_string()
+        })?;
+    let mut deprecations = HashMap::with_capacity(parsed.len());
+    for (model, entry) in parsed {
+        let model = model.trim().to_string();
+        if model.is_empty() {
+            return Err("model id must not be empty".to_string());
+        }
+        let deprecated_at = entry.deprecated_at.trim().to_string();
+        if !is_valid_iso_date(&deprecated_at) {
+            return Err(format!("invalid deprecated_at date {deprecated_at} for model {model}"));
+        }
+        let replacement =
+            entry.replacement.map(|value| value.trim().to_string()).filter(|v| !v.is_empty());
+        if replacement.as_deref() == Some(model.as_str()) {
+            return Err(format!("model {model} cannot replace itself"));
+        }
+        deprecations.insert(model, ModelDeprecation { deprecated_at, replacement });
+    }
+    Ok(deprecations)
+}
+
+fn parse_disabled_endpoints(raw: &str) -> Result<HashMap<EndpointClass, String>, String> {
+    let trimmed = raw.trim();
+    let entries = if trimmed.starts_with('{') {
+        serde_json::from_str::<BTreeMap<String, String>>(trimmed)
+            .map_err(|_| "expected a JSON object of outage messages".to_string())?
+            .into_iter()
+            .collect::<Vec<_>>()
+    } else {
+        parse_string_list(trimmed, &[])
+            .into_iter()
+            .map(|class| (class, String::new()))
+            .collect::<Vec<_>>()
+    };
+    let mut disabled = HashMap::with_capacity(entries.len());
+    for (class, message) in entries {
+        let endpoint = EndpointClass::parse(&class)
+            .ok_or_else(|| format!("unknown endpoint class {class}"))?;
+        let message = match message.trim() {
+            "" => format!("{} endpoint is temporarily disabled", endpoint.as_str()),
+            message => message.to_string(),
+        };
+        disabled.insert(endpoint, message);
+    }
+    Ok(disabled)
+}
+
+fn is_valid_iso_date(value: &str) -> bool {
+    let bytes = value.as_bytes();
+    let is_date_shape = bytes.len() == 10
+        && bytes.iter().enumerate().all(|(index, byte)| match index {
+            4 | 7 => *byte == b'-',
+            _ => byte.is_ascii_digit(),
+        });
+    if !is_date_shape {
+        return false;
+    }
+    let (Ok(year), Ok(month), Ok(day)) =
+        (value[0..4].parse::<u32>(), value[5..7].parse::<u32>(), value[8..10].parse::<u32>())
+    else {
+        return false;
+    };
+    let leap = year % 4 == 0 && (year % 100 != 0 || year % 400 == 0);
+    let days_in_month = match month {
+        1 | 3 | 5 | 7 | 8 | 10 | 12 => 31,
+        4 | 6 | 9 | 11 => 30,
+        2 if leap => 29,
+        2 => 28,
+        _ => return false,
+    };
+    (1..=days_in_month).contains(&day)
+}
+
+fn cors_from_env() -> Result<CorsConfig, ConfigError> {
+    let allowed_origins = parse_string_list_env("XR_CORS_ALLOWED_ORIGINS", &[]);
+    let allowed_headers =
+        parse_string_list_env("XR_CORS_ALLOWED_HEADERS", DEFAULT_CORS_ALLOWED_HEADERS)
+            .into_iter()
+            .map(|header| header.to_ascii_lowercase())
+            .collect::<Vec<_>>();
+    let max_age_raw = env::var("XR_CORS_MAX_AGE").unwrap_or_else(|_| "600".to_string());
+    let max_age_seconds = max_age_raw
+        .trim()
+        .parse::<u64>()
+        .map_err(|_| ConfigError::InvalidCorsMaxAge(max_age_raw.clone()))?;
+    let allow_credentials_raw =
+        env::var("XR_CORS_ALLOW_CREDENTIALS").unwrap_or_else(|_| "false".to_string());
+    let allow_credentials = parse_bool(&allow_credentials_raw).ok_or_else(|| {
+        ConfigError::InvalidCorsAllowCredentialsBool(allow_credentials_raw.clone())
+    })?;
+
+    for origin in &allowed_origins {
+        if !is_valid_cors_origin(origin) || (allow_credentials && origin == "*") {
+            return Err(ConfigError::InvalidCorsOrigin(origin.clone()));
+        }
+    }
+    for header in &allowed_headers {
+        if HeaderName::from_bytes(header.as_bytes()).is_err() {
+            return Err(ConfigError::InvalidCorsAllowedHeader(header.clone()));
+        }
+    }
+
+    Ok(CorsConfig { allowed_origins, allowed_headers, max_age_seconds, allow_credentials })
+}
+
+fn is_valid_cors_origin(origin: &str) -> bool {
+    if origin == "*" {
+        return true;
+    }
+    let Some((scheme, host)) = origin.split_once("://") else {
+        return false;
+    };
+    let host = host.strip_prefix("*.").unwrap_or(host);
+    matches!(scheme, "http" | "https")
+        && !host.is_empty()
+        && !host.contains(['/', '*'])
+        && HeaderValue::from_str(origin).is_ok()
 }
 
 fn default_provider_base_url(provider: &str) -> Option<&'static str> {
@@ -244,6 +767,14 @@ fn parse_bool(value: &str) -> Option<bool> {
     }
 }
 
+fn parse_assistant_prefill_policy(value: &str) -> Option<AssistantPrefillPolicy> {
+    match value.trim().to_ascii_lowercase().as_str() {
+        "emulate" => Some(AssistantPrefillPolicy::Emulate),
+        "reject" => Some(AssistantPrefillPolicy::Reject),
+        _ => None,
+    }
+}
+
 fn parse_positive_usize(value: &str) -> Option<usize> {
     let parsed = value.trim().parse::<usize>().ok()?;
     if parsed == 0 { None } else { Some(parsed) }
@@ -282,7 +813,12 @@ fn parse_string_list(trimmed: &str, default: &[&str]) -> Vec<String> {
 
 #[cfg(test)]
 mod tests {
-    use super::{DEFAULT_OPENROUTER_SUPPORTED_MODELS, parse_positive_usize, parse_string_list};
+    use super::{
+        AppConfig, ConfigIssueSeverity, DEFAULT_OPENROUTER_SUPPORTED_MODELS, EndpointClass,
+        ModelDeprecation, ProviderConfig, is_valid_cors_origin, is_valid_iso_date,
+        parse_disabled_endpoints, parse_model_deprecations, parse_positive_usize,
+        parse_provider_headers, parse_string_list,
+    };
 
     #[test]
     fn parse_string_list_accepts_json_array() {
@@ -308,4 +844,217 @@ mod tests {
         assert_eq!(parse_positive_usize("0"), None);
         assert_eq!(parse_positive_usize("abc"), None);
     }
+
+    #[test]
+    fn parse_provider_headers_accepts_key_template() {
+        let parsed =
+            parse_provider_headers(r#"{"X-Api-Version":"2024-10-21","api-key":"{{key}}"}"#);
+        assert_eq!(
+            parsed,
+            Ok(vec![
+                ("api-key".to_string(), "{{key}}".to_string()),
+                ("x-api-version".to_string(), "2024-10-21".to_string()),
+            ])
+        );
+    }
+
+    #[test]
+    fn parse_provider_headers_rejects_hop_by_hop_and_malformed_entries() {
+        assert!(parse_provider_headers(r#"{"Connection":"close"}"#).is_err());
+        assert!(parse_provider_headers(r#"{"Transfer-Encoding":"chunked"}"#).is_err());
+        assert!(parse_provider_headers(r#"{"bad header":"value"}"#).is_err());
+        assert!(parse_provider_headers(r#"["x-api-version"]"#).is_err());
+    }
+
+    #[test]
+    fn supports_suppress_bearer_excludes_non_bearer_providers() {
+        assert!(supports_suppress_bearer("xrouter"));
+        assert!(supports_suppress_bearer("deepseek"));
+        assert!(!supports_suppress_bearer("gigachat"));
+        assert!(!supports_suppress_bearer("yandex"));
+    }
+
+    #[test]
+    fn is_valid_cors_origin_accepts_exact_and_wildcard_subdomain_origins() {
+        assert!(is_valid_cors_origin("*"));
+        assert!(is_valid_cors_origin("https://playground.example.com"));
+        assert!(is_valid_cors_origin("https://*.example.com"));
+        assert!(is_valid_cors_origin("http://localhost:5173"));
+        assert!(!is_valid_cors_origin("example.com"));
+        assert!(!is_valid_cors_origin("https://example.com/path"));
+        assert!(!is_valid_cors_origin("https://app.*.example.com"));
+        assert!(!is_valid_cors_origin("ftp://example.com"));
+    }
+
+    #[test]
+    fn parse_model_deprecations_accepts_dates_and_optional_replacement() {
+        let parsed = parse_model_deprecations(
+            r#"{"deepseek/deepseek-chat":{"deprecated_at":"2026-03-01","replacement":"deepseek/deepseek-v3.2"},"zai/glm-4":{"deprecated_at":"2024-02-29"}}"#,
+        )
+        .expect("deprecations must parse");
+
+        assert_eq!(
+            parsed.get("deepseek/deepseek-chat"),
+            Some(&ModelDeprecation {
+                deprecated_at: "2026-03-01".to_string(),
+                replacement: Some("deepseek/deepseek-v3.2".to_string()),
+            })
+        );
+        assert_eq!(parsed.get("zai/glm-4").and_then(|entry| entry.replacement.clone()), None);
+    }
+
+    #[test]
+    fn parse_model_deprecations_rejects_bad_dates_and_unknown_fields() {
+        assert!(parse_model_deprecations(r#"{"m":{"deprecated_at":"2026-02-30"}}"#).is_err());
+        assert!(parse_model_deprecations(r#"{"m":{"deprecated_at":"01.03.2026"}}"#).is_err());
+        assert!(parse_model_deprecations(r#"{"m":{"deprecated":"2026-03-01"}}"#).is_err());
+        assert!(
+            parse_model_deprecations(r#"{"m":{"deprecated_at":"2026-03-01","replacement":"m"}}"#)
+                .is_err()
+        );
+        assert!(is_valid_iso_date("2000-02-29"));
+        assert!(!is_valid_iso_date("1900-02-29"));
+        assert!(!is_valid_iso_date("2026-13-01"));
+    }
+
+    #[test]
+    fn parse_disabled_endpoints_accepts_list_and_message_object() {
+        let parsed = parse_disabled_endpoints("chat, Responses").expect("list must parse");
+        assert_eq!(
+            parsed.get(&EndpointClass::Chat).map(String::as_str),
+            Some("chat endpoint is temporarily disabled")
+        );
+        assert!(parsed.contains_key(&EndpointClass::Responses));
+
+        let parsed = parse_disabled_endpoints(r#"{"chat":"Chat is paused until 18:00 UTC"}"#)
+            .expect("object must parse");
+        assert_eq!(
+            parsed.get(&EndpointClass::Chat).map(String::as_str),
+            Some("Chat is paused until 18:00 UTC")
+        );
+        assert!(!parsed.contains_key(&EndpointClass::Responses));
+    }
+
+    #[test]
+    fn parse_disabled_endpoints_rejects_unknown_classes() {
+        assert!(parse_disabled_endpoints("chat,images").is_err());
+        assert!(parse_disabled_endpoints(r#"{"midjourney":"off"}"#).is_err());
+        assert!(parse_disabled_endpoints(r#"{"chat":1}"#).is_err());
+    }
+
+    fn valid_config() -> AppConfig {
+        let mut config = AppConfig::for_tests();
+        for (name, provider) in &mut config.providers {
+            provider.enabled = name == "deepseek";
+        }
+        let deepseek = config.providers.get_mut("deepseek").expect("deepseek must be configured");
+        deepseek.api_key = Some("sk-test".to_string());
+        deepseek.base_url = Some("https://api.deepseek.com".to_string());
+        config
+    }
+
+    #[test]
+    fn validate_accepts_consistent_config() {
+        assert_eq!(valid_config().validate(), Vec::new());
+    }
+
+    fn provider<'a>(config: &'a mut AppConfig, name: &str) -> &'a mut ProviderConfig {
+        config.providers.get_mut(name).expect("provider must be configured")
+    }
+
+    #[test]
+    fn validate_reports_representative_misconfigurations() {
+        let cases: [(&str, fn(&mut AppConfig), ConfigIssueSeverity); 12] = [
+            ("XR_HOST", |c| c.host = "localhost".to_string(), ConfigIssueSeverity::Fatal),
+            (
+                "<PROVIDER>_ENABLED",
+                |c| c.providers.values_mut().for_each(|p| p.enabled = false),
+                ConfigIssueSeverity::Fatal,
+            ),
+            (
+                "DEEPSEEK_BASE_URL",
+                |c| provider(c, "deepseek").base_url = Some("api.deepseek.com".to_string()),
+                ConfigIssueSeverity::Fatal,
+            ),
+            (
+                "DEEPSEEK_BASE_URL",
+                |c| provider(c, "deepseek").base_url = None,
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "DEEPSEEK_API_KEY",
+                |c| provider(c, "deepseek").api_key = None,
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "DEEPSEEK_HEADERS",
+                |c| {
+                    let deepseek = provider(c, "deepseek");
+                    deepseek.api_key = None;
+                    deepseek.suppress_bearer = true;
+                    deepseek.headers = vec![("api-key".to_string(), "{{key}}".to_string())];
+                },
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "YANDEX_FOLDER_ID",
+                |c| provider(c, "yandex").enabled = true,
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "XR_BYOK_ENABLED",
+                |c| {
+                    c.byok_enabled = true;
+                    provider(c, "yandex").enabled = true;
+                },
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "GIGACHAT_CREDENTIALS",
+                |c| provider(c, "gigachat").enabled = true,
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "GIGACHAT_INSECURE_TLS",
+                |c| {
+                    c.gigachat_insecure_tls = true;
+                    provider(c, "gigachat").enabled = true;
+                },
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "XR_DISABLED_ENDPOINTS",
+                |c| {
+                    c.disabled_endpoints.insert(EndpointClass::Chat, "off".to_string());
+                    c.disabled_endpoints.insert(EndpointClass::Responses, "off".to_string());
+                },
+                ConfigIssueSeverity::Warning,
+            ),
+            (
+                "XR_MODEL_DEPRECATIONS",
+                |c| {
+                    for (model, replacement) in [("a/old", "a/mid"), ("a/mid", "a/new")] {
+                        c.model_deprecations.insert(
+                            model.to_string(),
+                            ModelDeprecation {
+                                deprecated_at: "2026-01-01".to_string(),
+                                replacement: Some(replacement.to_string()),
+                            },
+                        );
+                    }
+                },
+                ConfigIssueSeverity::Warning,
+            ),
+        ];
+
+        for (setting, mutate, severity) in cases {
+            let mut config = valid_config();
+            mutate(&mut config);
+            let issues = config.validate();
+            assert!(
+                issues.iter().any(|issue| issue.setting == setting && issue.severity == severity),
+                "expected {severity:?} issue for {setting}, got {issues:?}"
+            );
+        }
+    }
 }
@@ -1,4 +1,6 @@
-use axum::http::HeaderMap;
+use std::hash::{DefaultHasher, Hash, Hasher};
+
+use axum::http::{HeaderMap, HeaderValue, header};
 use tracing::info;
 use xrouter_core::CoreError;
 
@@ -30,7 +32,7 @@ pub(crate) fn resolve_byok_bearer(
 }
 
 pub(crate) fn parse_bearer_token(headers: &HeaderMap) -> Option<String> {
-    let raw = headers.get(axum::http::header::AUTHORIZATION)?.to_str().ok()?.trim();
+    let raw = headers.get(header::AUTHORIZATION)?.to_str().ok()?.trim();
     let (scheme, token) = raw.split_once(' ')?;
     if !scheme.eq_ignore_ascii_case("bearer") {
         return None;
@@ -38,3 +40,11 @@ pub(crate) fn parse_bearer_token(headers: &HeaderMap) -> Option<String> {
     let token = token.trim();
     if token.is_empty() { None } else { Some(token.to_string()) }
 }
+
+// Identifies the caller by its `Authorization` value without keeping the credential itself.
+pub(crate) fn authorization_hash(headers: &HeaderMap) -> u64 {
+    let authorization = headers.get(header::AUTHORIZATION).map(HeaderValue::as_bytes);
+    let mut hasher = DefaultHasher::new();
+    authorization.unwrap_or_default().hash(&mut hasher);
+    hasher.finish()
+}
@@ -0,0 +1,207 @@
+use std::{
+    collections::HashMap,
+    sync::{Mutex, PoisonError},
+    time::{Duration, Instant},
+};
+
+use tracing::{info, warn};
+use xrouter_core::CoreError;
+
+#[derive(Default)]
+struct Circuit {
+    consecutive_failures: u32,
+    opened_at: Option<Instant>,
+    probe_started_at: Option<Instant>,
+}
+
+pub(crate) struct CircuitBreakers {
+    failure_threshold: u32,
+    open_duration: Duration,
+    circuits: Mutex<HashMap<String, Circuit>>,
+}
+
+impl CircuitBreakers {
+    pub(crate) fn new(failure_threshold: u32, open_seconds: u64) -> Self {
+        Self {
+            failure_threshold,
+            open_duration: Duration::from_secs(open_seconds),
+            circuits: Mutex::new(HashMap::new()),
+        }
+    }
+
+    pub(crate) fn is_enabled(&self) -> bool {
+        self.failure_threshold > 0
+    }
+
+    pub(crate) fn try_acquire(&self, model: &str, route: &str) -> Result<(), CoreError> {
+        if !self.is_enabled() {
+            return Ok(());
+        }
+        let mut circuits = self.circuits.lock().unwrap_or_else(PoisonError::into_inner);
+        let Some(circuit) = circuits.get_mut(model) else {
+            return Ok(());
+        };
+        let Some(opened_at) = circuit.opened_at else {
+            return Ok(());
+        };
+        let now = Instant::now();
+        let open_for = now.duration_since(opened_at);
+        if open_for < self.open_duration {
+            let retry_after = (self.open_duration - open_for).as_secs().max(1);
+            info!(event = "http.circuit.rejected", route = route, model = model);
+            return Err(circuit_open_error(model, retry_after));
+        }
+        // Half-open: one probe at a time. A probe whose outcome was never recorded (the client
+        // went away mid-request) stops blocking after another open interval.
+        if circuit
+            .probe_started_at
+            .is_some_and(|started_at| now.duration_since(started_at) < self.open_duration)
+        {
+            info!(event = "http.circuit.rejected", route = route, model = model);
+            return Err(circuit_open_error(model, 1));
+        }
+        circuit.probe_started_at = Some(now);
+        info!(event = "http.circuit.probe", route = route, model = model);
+        Ok(())
+    }
+
+    pub(crate) fn record(&self, model: &str, error: Option<&CoreError>) {
+        if !self.is_enabled() {
+            return;
+        }
+        let mut circuits = self.circuits.lock().unwrap_or_else(PoisonError::into_inner);
+        match error {
+            None => {
+                if circuits.remove(model).is_some_and(|circuit| circuit.opened_at.is_some()) {
+                    info!(event = "http.circuit.closed", model = model);
+                }
+            }
+            Some(error) if is_circuit_failure(error) => {
+                let circuit = circuits.entry(model.to_string()).or_default();
+                let probe_failed = circuit.probe_started_at.take().is_some();
+                circuit.consecutive_failures = circuit.consecutive_failures.saturating_add(1);
+                if probe_failed || circuit.consecutive_failures >= self.failure_threshold {
+                    circuit.opened_at = Some(Instant::now());
+                    warn!(
+                        event = "http.circuit.opened",
+                        model = model,
+                        consecutive_failures = circuit.consecutive_failures,
+                        open_seconds = self.open_duration.as_secs(),
+                        error = %error
+                    );
+                }
+            }
+            Some(_) => {
+                // Client-side errors say nothing about upstream health; free the probe slot.
+                if let Some(circuit) = circuits.get_mut(model) {
+                    circuit.probe_started_at = None;
+                }
+            }
+        }
+    }
+}
+
+fn is_circuit_failure(error: &CoreError) -> bool {
+    match error {
+        CoreError::Provider(_) => true,
+        CoreError::UpstreamStatus { status, .. } => *status >= 500,
+        CoreError::Validation(_)
+        | CoreError::ClientDisconnected(_)
+        | CoreError::ProviderOverloaded(_)
+        | CoreError::ResponseTooLarge(_)
+        | CoreError::CircuitOpen { .. } => false,
+    }
+}
+
+fn circuit_open_error(model: &str, retry_after_seconds: u64) -> CoreError {
+    CoreError::CircuitOpen { model: model.to_string(), retry_after_seconds }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn upstream_error(status: u16) -> CoreError {
+        CoreError::UpstreamStatus {
+            status,
+            message: format!("provider returned error status: {status}"),
+            error: None,
+        }
+    }
+
+    #[test]
+    fn opens_after_threshold_of_consecutive_upstream_failures() {
+        let breakers = CircuitBreakers::new(2, 60);
+
+        breakers.record("deepseek/deepseek-chat", Some(&upstream_error(503)));
+        breakers.record("deepseek/deepseek-chat", None);
+        breakers.record("deepseek/deepseek-chat", Some(&upstream_error(502)));
+        assert!(breakers.try_acquire("deepseek/deepseek-chat", "/test").is_ok());
+
+        breakers.record("deepseek/deepseek-chat", Some(&upstream_error(500)));
+        let err = breakers
+            .try_acquire("deepseek/deepseek-chat", "/test")
+            .expect_err("circuit must open after two consecutive failures");
+        assert!(matches!(err, CoreError::CircuitOpen { .. }));
+        assert!(breakers.try_acquire("deepseek/deepseek-v3.2", "/test").is_ok());
+    }
+
+    #[test]
+    fn client_errors_and_local_limits_do_not_count() {
+        let breakers = CircuitBreakers::new(1, 60);
+
+        breakers.record("zai/glm-5", Some(&upstream_error(400)));
+        breakers.record("zai/glm-5", Some(&upstream_error(429)));
+        breakers.record("zai/glm-5", Some(&CoreError::Validation("bad input".to_string())));
+        breakers.record("zai/glm-5", Some(&CoreError::ProviderOverloaded("zai".to_string())));
+        breakers.record("zai/glm-5", Some(&CoreError::ResponseTooLarge("zai".to_string())));
+
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+    }
+
+    fn open_since(breakers: &CircuitBreakers, model: &str, elapsed: Duration) {
+        let mut circuits = breakers.circuits.lock().unwrap_or_else(PoisonError::into_inner);
+        circuits.insert(
+            model.to_string(),
+            Circuit {
+                consecutive_failures: breakers.failure_threshold,
+                opened_at: Some(Instant::now() - elapsed),
+                probe_started_at: None,
+            },
+        );
+    }
+
+    #[test]
+    fn half_open_allows_single_probe_and_closes_on_success() {
+        let breakers = CircuitBreakers::new(3, 60);
+        open_since(&breakers, "zai/glm-5", Duration::from_secs(61));
+
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_err());
+
+        breakers.record("zai/glm-5", None);
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+    }
+
+    #[test]
+    fn failed_probe_reopens_circuit() {
+        let breakers = CircuitBreakers::new(3, 60);
+        open_since(&breakers, "zai/glm-5", Duration::from_secs(61));
+
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+        breakers.record("zai/glm-5", Some(&upstream_error(504)));
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_err());
+    }
+
+    #[test]
+    fn zero_threshold_disables_breaker() {
+        let breakers = CircuitBreakers::new(0, 60);
+        for _ in 0..5 {
+            breakers.record("zai/glm-5", Some(&upstream_error(500)));
+        }
+
+        assert!(!breakers.is_enabled());
+        assert!(breakers.try_acquire("zai/glm-5", "/test").is_ok());
+    }
+}
@@ -0,0 +1,138 @@
+use std::sync::Arc;
+
+use axum::{
+    extract::{Request, State},
+    http::{HeaderMap, HeaderValue, Method, StatusCode, header},
+    middleware::Next,
+    response::{IntoResponse, Response},
+};
+use tracing::debug;
+
+use crate::{config::CorsConfig, http::errors::REQUEST_ID_HEADER};
+
+const ALLOWED_METHODS: &str = "GET, POST, OPTIONS";
+
+pub(crate) struct CorsPolicy {
+    allowed_origins: Vec<String>,
+    allowed_headers: Option<HeaderValue>,
+    max_age: HeaderValue,
+    allow_credentials: bool,
+}
+
+impl CorsPolicy {
+    pub(crate) fn from_config(config: &CorsConfig) -> Self {
+        Self {
+            allowed_origins: config.allowed_origins.clone(),
+            allowed_headers: HeaderValue::from_str(&config.allowed_headers.join(", ")).ok(),
+            max_age: HeaderValue::from(config.max_age_seconds),
+            allow_credentials: config.allow_credentials,
+        }
+    }
+
+    pub(crate) fn same_origin_only() -> Self {
+        Self {
+            allowed_origins: Vec::new(),
+            allowed_headers: None,
+            max_age: HeaderValue::from(0u64),
+            allow_credentials: false,
+        }
+    }
+
+    pub(crate) fn is_enabled(&self) -> bool {
+        !self.allowed_origins.is_empty()
+    }
+
+    fn allows_origin(&self, origin: &str) -> bool {
+        self.allowed_origins.iter().any(|pattern| origin_matches(pattern, origin))
+    }
+
+    fn apply_headers(&self, headers: &mut HeaderMap, origin: HeaderValue) {
+        headers.insert(header::ACCESS_CONTROL_ALLOW_ORIGIN, origin);
+        headers.append(header::VARY, HeaderValue::from_static("origin"));
+        headers.insert(
+            header::ACCESS_CONTROL_EXPOSE_HEADERS,
+            HeaderValue::from_static(REQUEST_ID_HEADER),
+        );
+        if self.allow_credentials {
+            headers
+                .insert(header::ACCESS_CONTROL_ALLOW_CREDENTIALS, HeaderValue::from_static("true"));
+        }
+    }
+
+    fn apply_preflight_headers(&self, headers: &mut HeaderMap, origin: HeaderValue) {
+        self.apply_headers(headers, origin);
+        headers.insert(
+            header::ACCESS_CONTROL_ALLOW_METHODS,
+            HeaderValue::from_static(ALLOWED_METHODS),
+        );
+        if let Some(allowed_headers) = &self.allowed_headers {
+            headers.insert(header::ACCESS_CONTROL_ALLOW_HEADERS, allowed_headers.clone());
+        }
+        headers.insert(header::ACCESS_CONTROL_MAX_AGE, self.max_age.clone());
+    }
+}
+
+pub(crate) async fn cors_middleware(
+    State(policy): State<Arc<CorsPolicy>>,
+    request: Request,
+    next: Next,
+) -> Response {
+    let Some(origin) = request
+        .headers()
+        .get(header::ORIGIN)
+        .filter(|origin| origin.to_str().is_ok_and(|origin| policy.allows_origin(origin)))
+        .cloned()
+    else {
+        return next.run(request).await;
+    };
+
+    if request.method() == Method::OPTIONS
+        && request.headers().contains_key(header::ACCESS_CONTROL_REQUEST_METHOD)
+    {
+        debug!(event = "http.cors.preflight", path = %request.uri().path());
+        let mut response = StatusCode::NO_CONTENT.into_response();
+        policy.apply_preflight_headers(response.headers_mut(), origin);
+        return response;
+    }
+
+    let mut response = next.run(request).await;
+    policy.apply_headers(response.headers_mut(), origin);
+    response
+}
+
+fn origin_matches(pattern: &str, origin: &str) -> bool {
+    if pattern == "*" {
+        return true;
+    }
+    if let Some((scheme, host_pattern)) = pattern.split_once("://")
+        && let Some(suffix) = host_pattern.strip_prefix("*.")
+    {
+        let Some((origin_scheme, origin_host)) = origin.split_once("://") else {
+            return false;
+        };
+        let origin_host = origin_host.to_ascii_lowercase();
+        let suffix = format!(".{}", suffix.to_ascii_lowercase());
+        return scheme.eq_ignore_ascii_case(origin_scheme)
+            && origin_host.len() > suffix.len()
+            && origin_host.ends_with(&suffix);
+    }
+    pattern.eq_ignore_ascii_case(origin)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::origin_matches;
+
+    #[test]
+    fn origin_matches_exact_and_wildcard_subdomains() {
+        assert!(origin_matches("*", "https://anything.test"));
+        assert!(origin_matches("https://app.example.com", "https://APP.example.com"));
+        assert!(origin_matches("https://*.example.com", "https://play.example.com"));
+        assert!(origin_matches("https://*.example.com", "https://a.b.example.com"));
+        assert!(!origin_matches("https://*.example.com", "https://example.com"));
+        assert!(!origin_matches("https://*.example.com", "http://play.example.com"));
+        assert!(!origin_matches("https://*.example.com", "https://play.example.com.evil.test"));
+        assert!(!origin_matches("https://*.example.com", "https://evilexample.com"));
+        assert!(!origin_matches("https://app.example.com", "https://app.example.com.evil.test"));
+    }
+}
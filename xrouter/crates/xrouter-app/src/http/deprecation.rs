@@ -0,0 +1,127 @@
+use std::{
+    collections::HashMap,
+    time::{SystemTime, UNIX_EPOCH},
+};
+
+use axum::http::HeaderMap;
+use tracing::info;
+use xrouter_core::CoreError;
+
+use crate::config::ModelDeprecation;
+
+pub(crate) const ALLOW_DEPRECATED_MODELS_HEADER: &str = "x-allow-deprecated-models";
+
+const SECONDS_PER_DAY: u64 = 86_400;
+
+pub(crate) fn check_model_deprecation(
+    deprecations: &HashMap<String, ModelDeprecation>,
+    model: &str,
+    headers: &HeaderMap,
+    route: &str,
+) -> Result<(), CoreError> {
+    let Some(deprecation) = deprecations.get(model) else {
+        return Ok(());
+    };
+    if allows_deprecated_models(headers) {
+        info!(event = "http.model_deprecation.allowed", route = route, model = model);
+        return Ok(());
+    }
+    match deprecation_error(model, deprecation, &today_utc()) {
+        Some(err) => {
+            info!(event = "http.model_deprecation.rejected", route = route, model = model);
+            Err(err)
+        }
+        None => Ok(()),
+    }
+}
+
+fn allows_deprecated_models(headers: &HeaderMap) -> bool {
+    headers
+        .get(ALLOW_DEPRECATED_MODELS_HEADER)
+        .and_then(|value| value.to_str().ok())
+        .is_some_and(|value| value.trim().eq_ignore_ascii_case("true"))
+}
+
+fn deprecation_error(
+    model: &str,
+    deprecation: &ModelDeprecation,
+    today: &str,
+) -> Option<CoreError> {
+    // Both dates are zero-padded YYYY-MM-DD, so lexicographic order is chronological.
+    if today < deprecation.deprecated_at.as_str() {
+        return None;
+    }
+    let message = match &deprecation.replacement {
+        Some(replacement) => format!(
+            "model is deprecated: {model} since {}; use {replacement} instead",
+            deprecation.deprecated_at
+        ),
+        None => format!("model is deprecated: {model} since {}", deprecation.deprecated_at),
+    };
+    Some(CoreError::Validation(message))
+}
+
+fn today_utc() -> String {
+    let seconds =
+        SystemTime::now().duration_since(UNIX_EPOCH).map_or(0, |elapsed| elapsed.as_secs());
+    civil_date_from_days((seconds / SECONDS_PER_DAY) as i64)
+}
+
+fn civil_date_from_days(days: i64) -> String {
+    let z = days + 719_468;
+    let era = z.div_euclid(146_097);
+    let day_of_era = z.rem_euclid(146_097);
+    let year_of_era =
+        (day_of_era - day_of_era / 1_460 + day_of_era / 36_524 - day_of_era / 146_096) / 365;
+    let day_of_year = day_of_era - (365 * year_of_era + year_of_era / 4 - year_of_era / 100);
+    let shifted_month = (5 * day_of_year + 2) / 153;
+    let day = day_of_year - (153 * shifted_month + 2) / 5 + 1;
+    let month = if shifted_month < 10 { shifted_month + 3 } else { shifted_month - 9 };
+    let year = year_of_era + era * 400 + i64::from(month <= 2);
+    format!("{year:04}-{month:02}-{day:02}")
+}
+
+#[cfg(test)]
+mod tests {
+    use axum::http::HeaderValue;
+
+    use super::*;
+
+    #[test]
+    fn civil_date_from_days_matches_known_dates() {
+        assert_eq!(civil_date_from_days(0), "1970-01-01");
+        assert_eq!(civil_date_from_days(11_016), "2000-02-29");
+        assert_eq!(civil_date_from_days(20_513), "2026-03-01");
+    }
+
+    #[test]
+    fn deprecation_error_applies_from_deprecation_date() {
+        let deprecation = ModelDeprecation {
+            deprecated_at: "2026-03-01".to_string(),
+            replacement: Some("deepseek/deepseek-v3.2".to_string()),
+        };
+
+        assert!(deprecation_error("deepseek/deepseek-chat", &deprecation, "2026-02-28").is_none());
+        let err = deprecation_error("deepseek/deepseek-chat", &deprecation, "2026-03-01")
+            .expect("model must be deprecated on its deprecation date");
+        assert_eq!(
+            err.to_string(),
+            "validation failed: model is deprecated: deepseek/deepseek-chat since 2026-03-01; \
+             use deepseek/deepseek-v3.2 instead"
+        );
+    }
+
+    #[test]
+    fn allow_header_bypasses_deprecation() {
+        let deprecations = HashMap::from([(
+            "zai/glm-4".to_string(),
+            ModelDeprecation { deprecated_at: "2000-01-01".to_string(), replacement: None },
+        )]);
+        let mut headers = HeaderMap::new();
+        assert!(check_model_deprecation(&deprecations, "zai/glm-4", &headers, "/test").is_err());
+        assert!(check_model_deprecation(&deprecations, "zai/glm-5", &headers, "/test").is_ok());
+
+        headers.insert(ALLOW_DEPRECATED_MODELS_HEADER, HeaderValue::from_static("true"));
+        assert!(check_model_deprecation(&deprecations, "zai/glm-4", &headers, "/test").is_ok());
+    }
+}
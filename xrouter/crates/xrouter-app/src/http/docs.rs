@@ -1,5 +1,7 @@
 use axum::{
     Router,
+    extract::DefaultBodyLimit,
+    middleware,
     routing::{get, post},
 };
 use serde::{Deserialize, Serialize};
@@ -9,11 +11,19 @@ use xrouter_contracts::{
     ChatCompletionsRequest, ChatCompletionsResponse, ResponsesRequest, ResponsesResponse,
 };
 
-use crate::AppState;
+use crate::{
+    AppState,
+    http::{
+        cors::cors_middleware, endpoint_switch::endpoint_switch_middleware,
+        idempotency::idempotency_middleware, request_id::request_id_middleware,
+    },
+};
 
 #[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
 pub(crate) struct HealthResponse {
     pub(crate) status: String,
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub(crate) disabled_endpoints: Vec<String>,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
@@ -22,6 +32,10 @@ pub(crate) struct CompatibleModelEntry {
     pub(crate) object: String,
     pub(crate) created: i64,
     pub(crate) owned_by: String,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub(crate) deprecated_at: Option<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub(crate) replacement: Option<String>,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
@@ -59,6 +73,10 @@ pub(crate) struct XrouterModelEntry {
     pub(crate) architecture: ModelArchitecture,
     pub(crate) top_provider: ModelTopProvider,
     pub(crate) per_request_limits: ModelPerRequestLimits,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub(crate) deprecated_at: Option<String>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub(crate) replacement: Option<String>,
 }
 
 #[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
@@ -66,9 +84,37 @@ pub(crate) struct XrouterModelsResponse {
     pub(crate) data: Vec<XrouterModelEntry>,
 }
 
+#[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
+pub(crate) struct ErrorBody {
+    pub(crate) message: String,
+    #[serde(rename = "type")]
+    pub(crate) kind: String,
+    pub(crate) param: Option<String>,
+    pub(crate) code: Option<String>,
+}
+
 #[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
 pub(crate) struct ErrorResponse {
-    pub(crate) error: String,
+    pub(crate) error: ErrorBody,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
+pub(crate) struct DryRunStage {
+    pub(crate) stage: String,
+    pub(crate) status: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub(crate) input_tokens: Option<u32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub(crate) error: Option<ErrorBody>,
+}
+
+#[derive(Debug, Clone, Serialize, Deserialize, ToSchema)]
+pub(crate) struct DryRunReport {
+    pub(crate) object: String,
+    pub(crate) accepted: bool,
+    pub(crate) model: String,
+    pub(crate) provider: String,
+    pub(crate) stages: Vec<DryRunStage>,
 }
 
 #[derive(OpenApi)]
@@ -82,7 +128,10 @@ pub(crate) struct ErrorResponse {
     components(
         schemas(
             HealthResponse,
+            ErrorBody,
             ErrorResponse,
+            DryRunStage,
+            DryRunReport,
             ModelArchitecture,
             ModelTopProvider,
             ModelPerRequestLimits,
@@ -111,7 +160,10 @@ struct XrouterApiDoc;
     components(
         schemas(
             HealthResponse,
+            ErrorBody,
             ErrorResponse,
+            DryRunStage,
+            DryRunReport,
             CompatibleModelEntry,
             CompatibleModelsResponse,
             ResponsesRequest,
@@ -128,10 +180,9 @@ struct OpenAiApiDoc;
 
 pub fn build_router(state: AppState) -> Router {
     let openai_compatible_api = state.openai_compatible_api;
-    let (router, openapi) = if openai_compatible_api {
+    let (api_router, openapi) = if openai_compatible_api {
         (
             Router::new()
-                .route("/health", get(crate::http::routes::basic::get_health))
                 .route("/v1/models", get(crate::http::routes::basic::get_compatible_models))
                 .route("/v1/responses", post(crate::http::routes::inference::post_responses))
                 .route(
@@ -143,7 +194,6 @@ pub fn build_router(state: AppState) -> Router {
     } else {
         (
             Router::new()
-                .route("/health", get(crate::http::routes::basic::get_health))
                 .route("/api/v1/models", get(crate::http::routes::basic::get_xrouter_models))
                 .route("/api/v1/responses", post(crate::http::routes::inference::post_responses))
                 .route(
@@ -154,7 +204,35 @@ pub fn build_router(state: AppState) -> Router {
         )
     };
 
-    router.with_state(state).merge(SwaggerUi::new("/docs").url("/openapi.json", openapi))
+    let api_router = if state.idempotency.is_enabled() {
+        api_router.layer(middleware::from_fn_with_state(
+            state.idempotency.clone(),
+            idempotency_middleware,
+        ))
+    } else {
+        api_router
+    };
+    let api_router = if state.endpoint_switches.is_enabled() {
+        api_router.layer(middleware::from_fn_with_state(
+            state.endpoint_switches.clone(),
+            endpoint_switch_middleware,
+        ))
+    } else {
+        api_router
+    };
+    let api_router = api_router.layer(DefaultBodyLimit::max(state.max_request_body_bytes));
+    let api_router = api_router.layer(middleware::from_fn(request_id_middleware));
+    let api_router = if state.cors.is_enabled() {
+        api_router.layer(middleware::from_fn_with_state(state.cors.clone(), cors_middleware))
+    } else {
+        api_router
+    };
+
+    Router::new()
+        .route("/health", get(crate::http::routes::basic::get_health))
+        .merge(api_router)
+        .with_state(state)
+        .merge(SwaggerUi::new("/docs").url("/openapi.json", openapi))
 }
 
 #[allow(dead_code)]
@@ -164,7 +242,16 @@ pub fn build_router(state: AppState) -> Router {
     request_body = ResponsesRequest,
     responses(
         (status = 200, description = "Responses API result", body = ResponsesResponse),
-        (status = 400, description = "Validation or provider error", body = ErrorResponse)
+        (status = 400, description = "Validation error", body = ErrorResponse),
+        (status = 401, description = "Missing BYOK bearer token", body = ErrorResponse),
+        (status = 404, description = "Model not found", body = ErrorResponse),
+        (status = 409, description = "Idempotency key reused with a different body", body = ErrorResponse),
+        (status = 410, description = "Model is past its deprecation date", body = ErrorResponse),
+        (status = 413, description = "Request body too large", body = ErrorResponse),
+        (status = 422, description = "Invalid request body", body = ErrorResponse),
+        (status = 429, description = "Provider in-flight limit reached", body = ErrorResponse),
+        (status = 502, description = "Upstream provider failure", body = ErrorResponse),
+        (status = 503, description = "Endpoint disabled by the operator or provider circuit open", body = ErrorResponse)
     ),
     tag = "xrouter-app"
 )]
@@ -177,7 +264,16 @@ fn post_responses_openai_doc() {}
     request_body = ChatCompletionsRequest,
     responses(
         (status = 200, description = "Chat Completions API result", body = ChatCompletionsResponse),
-        (status = 400, description = "Validation or provider error", body = ErrorResponse)
+        (status = 400, description = "Validation error", body = ErrorResponse),
+        (status = 401, description = "Missing BYOK bearer token", body = ErrorResponse),
+        (status = 404, description = "Model not found", body = ErrorResponse),
+        (status = 409, description = "Idempotency key reused with a different body", body = ErrorResponse),
+        (status = 410, description = "Model is past its deprecation date", body = ErrorResponse),
+        (status = 413, description = "Request body too large", body = ErrorResponse),
+        (status = 422, description = "Invalid request body", body = ErrorResponse),
+        (status = 429, description = "Provider in-flight limit reached", body = ErrorResponse),
+        (status = 502, description = "Upstream provider failure", body = ErrorResponse),
+        (status = 503, description = "Endpoint disabled by the operator or provider circuit open", body = ErrorResponse)
     ),
     tag = "xrouter-app"
 )]
@@ -0,0 +1,238 @@
+use std::{
+    collections::HashMap,
+    sync::{Mutex, PoisonError},
+    time::{Duration, Instant},
+};
+
+use axum::{
+    Json,
+    http::HeaderMap,
+    response::{IntoResponse, Response},
+};
+use tracing::info;
+use xrouter_contracts::{ResponsesRequest, StageName};
+use xrouter_core::{CoreError, synthesize_model_id};
+
+use crate::{
+    AppState,
+    http::auth::{authorization_hash, resolve_byok_bearer},
+    http::deprecation::check_model_deprecation,
+    http::docs::{DryRunReport, DryRunStage},
+    http::errors::{dry_run_rate_limited_response, error_body_for},
+    http::prefill::apply_assistant_prefill_policy,
+    http::request_id::RequestId,
+};
+
+pub(crate) const DRY_RUN_HEADER: &str = "x-dry-run";
+
+const DRY_RUN_WINDOW: Duration = Duration::from_secs(60);
+const DRY_RUN_STAGES: [&str; 4] = ["auth", "model_resolution", "ingest", "tokenize"];
+
+// Dry run budgets over one shared fixed window. Callers are keyed by a hash of their
+// `Authorization` header, so requests without one share a single budget. The global budget caps
+// callers that send a fresh header per request, and also bounds the caller map, which is cleared
+// when the window rolls over.
+pub(crate) struct DryRunLimiter {
+    max_per_caller: usize,
+    max_total: usize,
+    window: Mutex<DryRunWindow>,
+}
+
+struct DryRunWindow {
+    started_at: Instant,
+    total: usize,
+    callers: HashMap<u64, usize>,
+}
+
+impl DryRunLimiter {
+    pub(crate) fn new(max_per_caller: usize, max_total: usize) -> Self {
+        Self {
+            max_per_caller,
+            max_total,
+            window: Mutex::new(DryRunWindow {
+                started_at: Instant::now(),
+                total: 0,
+                callers: HashMap::new(),
+            }),
+        }
+    }
+
+    pub(crate) fn try_acquire(&self, caller: u64) -> bool {
+        let mut window = self.window.lock().unwrap_or_else(PoisonError::into_inner);
+        let now = Instant::now();
+        if now.duration_since(window.started_at) >= DRY_RUN_WINDOW {
+            window.started_at = now;
+            window.total = 0;
+            window.callers.clear();
+        }
+        if window.total >= self.max_total {
+            return false;
+        }
+        let count = window.callers.entry(caller).or_insert(0);
+        if *count >= self.max_per_caller {
+            return false;
+        }
+        *count += 1;
+        window.total += 1;
+        true
+    }
+}
+
+pub(crate) fn is_dry_run(headers: &HeaderMap) -> bool {
+    headers
+        .get(DRY_RUN_HEADER)
+        .and_then(|value| value.to_str().ok())
+        .is_some_and(|value| value.trim().eq_ignore_ascii_case("true"))
+}
+
+pub(crate) fn dry_run_response(
+    state: &AppState,
+    headers: &HeaderMap,
+    route: &str,
+    request_id: &RequestId,
+    request: ResponsesRequest,
+) -> Response {
+    if !state.dry_run_limiter.try_acquire(authorization_hash(headers)) {
+        info!(event = "http.dry_run.rate_limited", route = route);
+        return dry_run_rate_limited_response(request_id.as_str());
+    }
+
+    let report = build_report(state, headers, route, request);
+    info!(
+        event = "http.dry_run.completed",
+        route = route,
+        model = %report.model,
+        provider = %report.provider,
+        accepted = report.accepted
+    );
+    Json(report).into_response()
+}
+
+fn build_report(
+    state: &AppState,
+    headers: &HeaderMap,
+    route: &str,
+    mut request: ResponsesRequest,
+) -> DryRunReport {
+    let provider = state.resolve_provider_key(&request.model);
+    let provider_model = state.resolve_provider_model_id(&request.model);
+    let model = synthesize_model_id(&provider, &provider_model);
+    request.model = provider_model;
+
+    let mut stages = Vec::with_capacity(DRY_RUN_STAGES.len());
+    let failure =
+        run_stages(state, headers, route, &provider, &model, &mut request, &mut stages).err();
+    let accepted = failure.is_none();
+    if let Some((stage, error)) = failure {
+        stages.push(DryRunStage {
+            stage: stage.to_string(),
+            status: "failed".to_string(),
+            input_tokens: None,
+            error: Some(error_body_for(&error)),
+        });
+    }
+    for stage in DRY_RUN_STAGES.iter().skip(stages.len()) {
+        stages.push(DryRunStage {
+            stage: (*stage).to_string(),
+            status: "skipped".to_string(),
+            input_tokens: None,
+            error: None,
+        });
+    }
+
+    DryRunReport { object: "dry_run".to_string(), accepted, model, provider, stages }
+}
+
+fn run_stages(
+    state: &AppState,
+    headers: &HeaderMap,
+    route: &str,
+    provider: &str,
+    model: &str,
+    request: &mut ResponsesRequest,
+    stages: &mut Vec<DryRunStage>,
+) -> Result<(), (&'static str, CoreError)> {
+    resolve_byok_bearer(headers, state.byok_enabled, provider, route)
+        .map_err(|error| ("auth", error))?;
+    stages.push(passed_stage("auth", None));
+
+    let engine =
+        state.resolve_engine(&request.model).map_err(|error| ("model_resolution", error))?;
+    apply_assistant_prefill_policy(state.assistant_prefill, &engine, provider, route, request)
+        .map_err(|error| ("model_resolution", error))?;
+    check_model_deprecation(&state.model_deprecations, model, headers, route)
+        .map_err(|error| ("model_resolution", error))?;
+    stages.push(passed_stage("model_resolution", None));
+
+    let report = engine
+        .preflight(request)
+        .map_err(|failure| (stage_label(&failure.stage), failure.error))?;
+    stages.push(passed_stage("ingest", None));
+    stages.push(passed_stage("tokenize", Some(report.input_tokens)));
+    Ok(())
+}
+
+fn passed_stage(stage: &str, input_tokens: Option<u32>) -> DryRunStage {
+    DryRunStage { stage: stage.to_string(), status: "ok".to_string(), input_tokens, error: None }
+}
+
+fn stage_label(stage: &StageName) -> &'static str {
+    match stage {
+        StageName::Ingest => "ingest",
+        StageName::Tokenize => "tokenize",
+        StageName::Generate => "generate",
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use axum::http::HeaderValue;
+
+    use super::*;
+
+    #[test]
+    fn dry_run_limiter_rejects_after_window_budget() {
+        let limiter = DryRunLimiter::new(2, 10);
+
+        assert!(limiter.try_acquire(1));
+        assert!(limiter.try_acquire(1));
+        assert!(!limiter.try_acquire(1));
+        assert!(limiter.try_acquire(2));
+    }
+
+    #[test]
+    fn dry_run_limiter_caps_all_callers_together() {
+        let limiter = DryRunLimiter::new(1, 2);
+
+        assert!(limiter.try_acquire(1));
+        assert!(limiter.try_acquire(2));
+        assert!(!limiter.try_acquire(3));
+    }
+
+    #[test]
+    fn dry_run_limiter_resets_expired_window() {
+        let limiter = DryRunLimiter::new(1, 2);
+        assert!(limiter.try_acquire(1));
+        assert!(limiter.try_acquire(2));
+        assert!(!limiter.try_acquire(1));
+
+        limiter.window.lock().unwrap_or_else(PoisonError::into_inner).started_at -= DRY_RUN_WINDOW;
+
+        assert!(limiter.try_acquire(1));
+        let window = limiter.window.lock().unwrap_or_else(PoisonError::into_inner);
+        assert_eq!(window.total, 1);
+        assert_eq!(window.callers.len(), 1);
+    }
+
+    #[test]
+    fn is_dry_run_accepts_only_true() {
+        let mut headers = HeaderMap::new();
+        assert!(!is_dry_run(&headers));
+
+        headers.insert(DRY_RUN_HEADER, HeaderValue::from_static("TRUE"));
+        assert!(is_dry_run(&headers));
+
+        headers.insert(DRY_RUN_HEADER, HeaderValue::from_static("false"));
+        assert!(!is_dry_run(&headers));
+    }
+}
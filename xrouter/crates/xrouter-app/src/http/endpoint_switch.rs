@@ -0,0 +1,89 @@
+use std::{collections::HashMap, sync::Arc};
+
+use axum::{
+    extract::{Request, State},
+    middleware::Next,
+    response::Response,
+};
+use tracing::info;
+
+use crate::{
+    config::EndpointClass,
+    http::{errors::endpoint_disabled_response, request_id::RequestId},
+};
+
+pub(crate) struct EndpointSwitches {
+    disabled: HashMap<EndpointClass, String>,
+}
+
+impl EndpointSwitches {
+    pub(crate) fn new(disabled: HashMap<EndpointClass, String>) -> Self {
+        Self { disabled }
+    }
+
+    pub(crate) fn is_enabled(&self) -> bool {
+        !self.disabled.is_empty()
+    }
+
+    pub(crate) fn disabled_classes(&self) -> Vec<String> {
+        let mut classes = self.disabled.keys().copied().collect::<Vec<_>>();
+        classes.sort();
+        classes.into_iter().map(|class| class.as_str().to_string()).collect()
+    }
+
+    fn outage_message(&self, path: &str) -> Option<(EndpointClass, &str)> {
+        let class = endpoint_class(path)?;
+        self.disabled.get(&class).map(|message| (class, message.as_str()))
+    }
+}
+
+pub(crate) async fn endpoint_switch_middleware(
+    State(switches): State<Arc<EndpointSwitches>>,
+    request: Request,
+    next: Next,
+) -> Response {
+    let Some((class, message)) = switches.outage_message(request.uri().path()) else {
+        return next.run(request).await;
+    };
+    info!(
+        event = "http.endpoint.disabled",
+        route = %request.uri().path(),
+        endpoint_class = class.as_str()
+    );
+    endpoint_disabled_response(message, RequestId::from_extensions(request.extensions()).as_str())
+}
+
+fn endpoint_class(path: &str) -> Option<EndpointClass> {
+    if path.ends_with("/chat/completions") {
+        Some(EndpointClass::Chat)
+    } else if path.ends_with("/responses") {
+        Some(EndpointClass::Responses)
+    } else {
+        None
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn outage_message_matches_disabled_route_class_only() {
+        let switches = EndpointSwitches::new(HashMap::from([(
+            EndpointClass::Chat,
+            "chat is paused".to_string(),
+        )]));
+
+        assert_eq!(
+            switches.outage_message("/api/v1/chat/completions"),
+            Some((EndpointClass::Chat, "chat is paused"))
+        );
+        assert_eq!(
+            switches.outage_message("/v1/chat/completions").map(|(_, m)| m),
+            Some("chat is paused")
+        );
+        assert_eq!(switches.outage_message("/api/v1/responses"), None);
+        assert_eq!(switches.outage_message("/api/v1/models"), None);
+        assert_eq!(switches.disabled_classes(), vec!["chat".to_string()]);
+    }
+}
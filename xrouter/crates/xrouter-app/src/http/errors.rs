@@ -1,30 +1,245 @@
 use axum::{
     Json,
+    extract::rejection::BytesRejection,
+    http::{HeaderMap, HeaderValue, StatusCode, header},
     response::{IntoResponse, Response},
 };
-use tracing::{error, warn};
+use serde_json::Value;
+use tracing::{error, info, warn};
 use xrouter_core::CoreError;
 
-use crate::http::docs::ErrorResponse;
+use crate::http::docs::{ErrorBody, ErrorResponse};
 
-pub(crate) fn error_response(err: CoreError) -> Response {
-    let status = match &err {
-        CoreError::Provider(message) if is_provider_overloaded(message) => {
-            axum::http::StatusCode::TOO_MANY_REQUESTS
-        }
-        _ => axum::http::StatusCode::BAD_REQUEST,
-    };
+pub(crate) const REQUEST_ID_HEADER: &str = "x-request-id";
+
+const INVALID_REQUEST_ERROR: &str = "invalid_request_error";
+const AUTHENTICATION_ERROR: &str = "authentication_error";
+const PERMISSION_ERROR: &str = "permission_error";
+const RATE_LIMIT_ERROR: &str = "rate_limit_error";
+const API_ERROR: &str = "api_error";
+
+pub(crate) fn error_response(err: CoreError, request_id: &str) -> Response {
+    let (status, body) = classify_error(&err);
     match &err {
-        CoreError::Validation(_) | CoreError::Provider(_) => {
-            warn!(event = "http.error_response", error = %err);
+        CoreError::Validation(_)
+        | CoreError::Provider(_)
+        | CoreError::UpstreamStatus { .. }
+        | CoreError::ProviderOverloaded(_)
+        | CoreError::ResponseTooLarge(_)
+        | CoreError::CircuitOpen { .. } => {
+            warn!(
+                event = "http.error_response",
+                request_id = %request_id,
+                status = status.as_u16(),
+                code = body.code.as_deref().unwrap_or_default(),
+                error = %err
+            );
         }
         CoreError::ClientDisconnected(_) => {
-            error!(event = "http.error_response", error = %err);
+            error!(
+                event = "http.error_response",
+                request_id = %request_id,
+                status = status.as_u16(),
+                code = body.code.as_deref().unwrap_or_default(),
+                error = %err
+            );
+        }
+    }
+    let mut response = envelope_response(status, body, request_id);
+    if let CoreError::CircuitOpen { retry_after_seconds, .. } = &err {
+        response.headers_mut().insert(header::RETRY_AFTER, HeaderValue::from(*retry_after_seconds));
+    }
+    response
+}
+
+pub(crate) fn invalid_request_body_response(request_id: &str) -> Response {
+    envelope_response(
+        StatusCode::UNPROCESSABLE_ENTITY,
+        error_body(INVALID_REQUEST_ERROR, "invalid_request_body", "invalid request body"),
+        request_id,
+    )
+}
+
+pub(crate) fn request_body_rejected_response(
+    route: &str,
+    headers: &HeaderMap,
+    rejection: BytesRejection,
+    request_id: &str,
+) -> Response {
+    let content_length = headers.get(header::CONTENT_LENGTH).and_then(|v| v.to_str().ok());
+    if rejection.status() != StatusCode::PAYLOAD_TOO_LARGE {
+        info!(
+            event = "http.request.body_unreadable",
+            route = route,
+            content_length = content_length.unwrap_or_default(),
+            error = %rejection.body_text()
+        );
+        return invalid_request_body_response(request_id);
+    }
+    warn!(
+        event = "http.request.body_too_large",
+        route = route,
+        content_length = content_length.unwrap_or_default()
+    );
+    envelope_response(
+        StatusCode::PAYLOAD_TOO_LARGE,
+        error_body(INVALID_REQUEST_ERROR, "request_too_large", "request body is too large"),
+        request_id,
+    )
+}
+
+pub(crate) fn dry_run_rate_limited_response(request_id: &str) -> Response {
+    envelope_response(
+        StatusCode::TOO_MANY_REQUESTS,
+        error_body(RATE_LIMIT_ERROR, "dry_run_rate_limited", "dry run rate limit exceeded"),
+        request_id,
+    )
+}
+
+pub(crate) fn idempotency_key_reused_response(request_id: &str) -> Response {
+    envelope_response(
+        StatusCode::CONFLICT,
+        error_body(
+            INVALID_REQUEST_ERROR,
+            "idempotency_key_reused",
+            "idempotency key was already used with a different request body",
+        ),
+        request_id,
+    )
+}
+
+pub(crate) fn endpoint_disabled_response(message: &str, request_id: &str) -> Response {
+    envelope_response(
+        StatusCode::SERVICE_UNAVAILABLE,
+        error_body(API_ERROR, "endpoint_disabled", message),
+        request_id,
+    )
+}
+
+pub(crate) fn error_body_for(err: &CoreError) -> ErrorBody {
+    classify_error(err).1
+}
+
+fn classify_error(err: &CoreError) -> (StatusCode, ErrorBody) {
+    let message = err.to_string();
+    match err {
+        CoreError::Validation(detail) if is_missing_bearer(detail) => (
+            StatusCode::UNAUTHORIZED,
+            error_body(AUTHENTICATION_ERROR, "missing_authorization", &message),
+        ),
+        CoreError::Validation(detail) if is_byok_not_supported(detail) => (
+            StatusCode::BAD_REQUEST,
+            error_body(INVALID_REQUEST_ERROR, "byok_not_supported", &message),
+        ),
+        CoreError::Validation(detail) if is_assistant_prefill_not_supported(detail) => (
+            StatusCode::BAD_REQUEST,
+            error_body(INVALID_REQUEST_ERROR, "assistant_prefill_not_supported", &message),
+        ),
+        CoreError::Validation(detail) if is_model_deprecated(detail) => (
+            StatusCode::GONE,
+            ErrorBody {
+                param: Some("model".to_string()),
+                ..error_body(INVALID_REQUEST_ERROR, "model_deprecated", &message)
+            },
+        ),
+        CoreError::Validation(detail) if is_model_not_found(detail) => {
+            (StatusCode::NOT_FOUND, error_body(INVALID_REQUEST_ERROR, "model_not_found", &message))
+        }
+        CoreError::Validation(_) => (
+            StatusCode::BAD_REQUEST,
+            error_body(INVALID_REQUEST_ERROR, "invalid_request", &message),
+        ),
+        CoreError::ResponseTooLarge(_) => (
+            StatusCode::BAD_GATEWAY,
+            error_body(API_ERROR, "upstream_response_too_large", &message),
+        ),
+        CoreError::CircuitOpen { .. } => (
+            StatusCode::SERVICE_UNAVAILABLE,
+            error_body(API_ERROR, "provider_circuit_open", &message),
+        ),
+        CoreError::ProviderOverloaded(_) => (
+            StatusCode::TOO_MANY_REQUESTS,
+            error_body(RATE_LIMIT_ERROR, "provider_overloaded", &message),
+        ),
+        CoreError::Provider(_) => {
+            (StatusCode::BAD_GATEWAY, error_body(API_ERROR, "provider_error", &message))
+        }
+        CoreError::UpstreamStatus { status, error, .. } => {
+            let status = upstream_status_to_response_status(*status);
+            let body = error.as_ref().and_then(passthrough_upstream_error).unwrap_or_else(|| {
+                error_body(error_type_for_status(status), "upstream_error", &message)
+            });
+            (status, body)
         }
+        CoreError::ClientDisconnected(_) => (
+            StatusCode::BAD_REQUEST,
+            error_body(INVALID_REQUEST_ERROR, "client_disconnected", &message),
+        ),
     }
-    (status, Json(ErrorResponse { error: err.to_string() })).into_response()
 }
 
-fn is_provider_overloaded(message: &str) -> bool {
-    message.starts_with("provider overloaded:")
+fn upstream_status_to_response_status(status: u16) -> StatusCode {
+    match StatusCode::from_u16(status) {
+        Ok(status) if status.is_client_error() => status,
+        _ => StatusCode::BAD_GATEWAY,
+    }
+}
+
+fn error_type_for_status(status: StatusCode) -> &'static str {
+    match status {
+        StatusCode::UNAUTHORIZED => AUTHENTICATION_ERROR,
+        StatusCode::FORBIDDEN => PERMISSION_ERROR,
+        StatusCode::TOO_MANY_REQUESTS => RATE_LIMIT_ERROR,
+        status if status.is_client_error() => INVALID_REQUEST_ERROR,
+        _ => API_ERROR,
+    }
+}
+
+fn passthrough_upstream_error(error: &Value) -> Option<ErrorBody> {
+    let message = error.get("message")?.as_str()?.to_string();
+    let kind = error.get("type").and_then(Value::as_str).unwrap_or(API_ERROR).to_string();
+    let param = error.get("param").and_then(Value::as_str).map(str::to_string);
+    let code = match error.get("code") {
+        Some(Value::String(code)) => Some(code.clone()),
+        Some(Value::Number(code)) => Some(code.to_string()),
+        _ => None,
+    };
+    Some(ErrorBody { message, kind, param, code })
+}
+
+fn error_body(kind: &str, code: &str, message: &str) -> ErrorBody {
+    ErrorBody {
+        message: message.to_string(),
+        kind: kind.to_string(),
+        param: None,
+        code: Some(code.to_string()),
+    }
+}
+
+fn envelope_response(status: StatusCode, body: ErrorBody, request_id: &str) -> Response {
+    let mut response = (status, Json(ErrorResponse { error: body })).into_response();
+    if let Ok(value) = HeaderValue::from_str(request_id) {
+        response.headers_mut().insert(REQUEST_ID_HEADER, value);
+    }
+    response
+}
+
+fn is_missing_bearer(message: &str) -> bool {
+    message.starts_with("authorization bearer token is required")
+}
+
+fn is_byok_not_supported(message: &str) -> bool {
+    message.starts_with("BYOK is not supported")
+}
+
+fn is_assistant_prefill_not_supported(message: &str) -> bool {
+    message.starts_with("assistant prefill is not supported")
+}
+
+fn is_model_deprecated(message: &str) -> bool {
+    message.starts_with("model is deprecated:")
+}
+
+fn is_model_not_found(message: &str) -> bool {
+    message.starts_with("unsupported provider for model:")
 }
@@ -0,0 +1,342 @@
+use std::{
+    collections::{HashMap, VecDeque},
+    hash::{DefaultHasher, Hash, Hasher},
+    sync::{Arc, Mutex, PoisonError},
+    time::{Duration, Instant},
+};
+
+use axum::{
+    body::{Body, Bytes, to_bytes},
+    extract::{FromRequest, Request, State},
+    http::{HeaderMap, HeaderValue, Method, StatusCode, header},
+    middleware::Next,
+    response::Response,
+};
+use serde_json::Value;
+use tokio::sync::OnceCell;
+use tracing::{debug, info, warn};
+use xrouter_core::CoreError;
+
+use crate::http::{
+    dry_run::is_dry_run,
+    errors::{error_response, idempotency_key_reused_response, request_body_rejected_response},
+    request_id::RequestId,
+};
+
+pub(crate) const IDEMPOTENCY_KEY_HEADER: &str = "idempotency-key";
+pub(crate) const IDEMPOTENT_REPLAY_HEADER: &str = "idempotent-replayed";
+
+const MAX_IDEMPOTENCY_KEY_LEN: usize = 255;
+const DEFAULT_MAX_IDEMPOTENCY_ENTRIES: usize = 10_000;
+
+#[derive(Clone)]
+struct StoredResponse {
+    status: StatusCode,
+    headers: HeaderMap,
+    body: Bytes,
+}
+
+struct IdempotencyEntry {
+    request_hash: u64,
+    created_at: Instant,
+    slot: Arc<OnceCell<StoredResponse>>,
+}
+
+// Keys in reservation order. With a single TTL the front is always the next key to expire, so
+// expiry and capacity eviction pop from the front. Released keys stay queued until they reach the
+// front; `created_at` tells them apart from a later reservation of the same key.
+#[derive(Default)]
+struct IdempotencyEntries {
+    by_scope: HashMap<String, IdempotencyEntry>,
+    order: VecDeque<(Instant, String)>,
+}
+
+impl IdempotencyEntries {
+    fn pop_oldest(&mut self) {
+        let Some((created_at, scope)) = self.order.pop_front() else {
+            return;
+        };
+        if self.by_scope.get(&scope).is_some_and(|entry| entry.created_at == created_at) {
+            self.by_scope.remove(&scope);
+        }
+    }
+}
+
+pub(crate) struct IdempotencyStore {
+    ttl: Duration,
+    max_entries: usize,
+    max_response_bytes: usize,
+    entries: Mutex<IdempotencyEntries>,
+}
+
+enum Reservation {
+    Slot(Arc<OnceCell<StoredResponse>>),
+    Conflict,
+}
+
+impl IdempotencyStore {
+    pub(crate) fn new(ttl_seconds: u64, max_response_bytes: usize) -> Self {
+        Self::with_max_entries(ttl_seconds, max_response_bytes, DEFAULT_MAX_IDEMPOTENCY_ENTRIES)
+    }
+
+    fn with_max_entries(ttl_seconds: u64, max_response_bytes: usize, max_entries: usize) -> Self {
+        Self {
+            ttl: Duration::from_secs(ttl_seconds),
+            max_entries,
+            max_response_bytes,
+            entries: Mutex::new(IdempotencyEntries::default()),
+        }
+    }
+
+    pub(crate) fn is_enabled(&self) -> bool {
+        !self.ttl.is_zero()
+    }
+
+    fn reserve(&self, scope: &str, request_hash: u64) -> Reservation {
+        let mut entries = self.entries.lock().unwrap_or_else(PoisonError::into_inner);
+        let now = Instant::now();
+        while entries
+            .order
+            .front()
+            .is_some_and(|(created_at, _)| now.duration_since(*created_at) >= self.ttl)
+        {
+            entries.pop_oldest();
+        }
+        match entries.by_scope.get(scope) {
+            Some(entry) if entry.request_hash != request_hash => Reservation::Conflict,
+            Some(entry) => Reservation::Slot(entry.slot.clone()),
+            None => {
+                while entries.order.len() >= self.max_entries {
+                    entries.pop_oldest();
+                    debug!(event = "http.idempotency.evicted", reason = "capacity");
+                }
+                let slot = Arc::new(OnceCell::new());
+                entries.by_scope.insert(
+                    scope.to_string(),
+                    IdempotencyEntry { request_hash, created_at: now, slot: slot.clone() },
+                );
+                entries.order.push_back((now, scope.to_string()));
+                Reservation::Slot(slot)
+            }
+        }
+    }
+
+    fn release(&self, scope: &str, slot: &Arc<OnceCell<StoredResponse>>) {
+        let mut entries = self.entries.lock().unwrap_or_else(PoisonError::into_inner);
+        if entries.by_scope.get(scope).is_some_and(|entry| Arc::ptr_eq(&entry.slot, slot)) {
+            entries.by_scope.remove(scope);
+        }
+    }
+}
+
+pub(crate) async fn idempotency_middleware(
+    State(store): State<Arc<IdempotencyStore>>,
+    request: Request,
+    next: Next,
+) -> Response {
+    if request.method() != Method::POST || is_dry_run(request.headers()) {
+        return next.run(request).await;
+    }
+    let Some(key) = request
+        .headers()
+        .get(IDEMPOTENCY_KEY_HEADER)
+        .and_then(|value| value.to_str().ok())
+        .map(str::trim)
+        .filter(|value| !value.is_empty() && value.len() <= MAX_IDEMPOTENCY_KEY_LEN)
+        .map(str::to_string)
+    else {
+        return next.run(request).await;
+    };
+
+    let (parts, body) = request.into_parts();
+    let request_id = RequestId::from_extensions(&parts.extensions);
+    let body = match Bytes::from_request(Request::from_parts(parts.clone(), body), &()).await {
+        Ok(body) => body,
+        Err(rejection) => {
+            return request_body_rejected_response(
+                parts.uri.path(),
+                &parts.headers,
+                rejection,
+                request_id.as_str(),
+            );
+        }
+    };
+    if is_stream_request(&body) {
+        debug!(event = "http.idempotency.skipped", route = %parts.uri.path(), reason = "stream");
+        return next.run(Request::from_parts(parts, Body::from(body))).await;
+    }
+
+    let route = parts.uri.path().to_string();
+    let scope = idempotency_scope(&route, &parts.headers, &key);
+    let request_hash = hash_bytes(&body);
+    // Requests that waited on an attempt that failed run again: the failed response is not
+    // stored, so they reserve the key anew and one of them becomes the next attempt.
+    loop {
+        let slot = match store.reserve(&scope, request_hash) {
+            Reservation::Slot(slot) => slot,
+            Reservation::Conflict => {
+                info!(event = "http.idempotency.conflict", route = %route);
+                return idempotency_key_reused_response(request_id.as_str());
+            }
+        };
+
+        let mut executed = false;
+        let stored = slot
+            .get_or_init(|| {
+                executed = true;
+                let request = Request::from_parts(parts.clone(), Body::from(body.clone()));
+                let next = next.clone();
+                let max_bytes = store.max_response_bytes;
+                let request_id = request_id.clone();
+                async move {
+                    store_response(next.run(request).await, max_bytes, request_id.as_str()).await
+                }
+            })
+            .await
+            .clone();
+
+        if !stored.status.is_success() {
+            store.release(&scope, &slot);
+            if !executed {
+                debug!(
+                    event = "http.idempotency.retrying",
+                    route = %route,
+                    status = stored.status.as_u16()
+                );
+                continue;
+            }
+        }
+        if !executed {
+            info!(
+                event = "http.idempotency.replayed",
+                route = %route,
+                status = stored.status.as_u16()
+            );
+        }
+        return replay_response(stored, !executed);
+    }
+}
+
+fn is_stream_request(body: &[u8]) -> bool {
+    serde_json::from_slice::<Value>(body)
+        .ok()
+        .and_then(|payload| payload.get("stream").and_then(Value::as_bool))
+        .unwrap_or(false)
+}
+
+fn idempotency_scope(route: &str, headers: &HeaderMap, key: &str) -> String {
+    let authorization = headers.get(header::AUTHORIZATION).map(HeaderValue::as_bytes);
+    format!("{route}:{:016x}:{key}", hash_bytes(authorization.unwrap_or_default()))
+}
+
+fn hash_bytes(bytes: &[u8]) -> u64 {
+    let mut hasher = DefaultHasher::new();
+    bytes.hash(&mut hasher);
+    hasher.finish()
+}
+
+// Responses are buffered up to the upstream response limit; a larger body fails the request the
+// same way an oversized provider response does, and is not stored.
+async fn store_response(response: Response, max_bytes: usize, request_id: &str) -> StoredResponse {
+    let (parts, body) = response.into_parts();
+    match to_bytes(body, max_bytes).await {
+        Ok(body) => StoredResponse { status: parts.status, headers: parts.headers, body },
+        Err(err) => {
+            warn!(event = "http.idempotency.store_failed", error = %err);
+            let error = CoreError::ResponseTooLarge(format!("limit is {max_bytes} bytes"));
+            let (parts, body) = error_response(error, request_id).into_parts();
+            let body = to_bytes(body, usize::MAX).await.unwrap_or_default();
+            StoredResponse { status: parts.status, headers: parts.headers, body }
+        }
+    }
+}
+
+fn replay_response(stored: StoredResponse, replayed: bool) -> Response {
+    let mut response = Response::new(Body::from(stored.body));
+    *response.status_mut() = stored.status;
+    *response.headers_mut() = stored.headers;
+    if replayed {
+        response.headers_mut().insert(IDEMPOTENT_REPLAY_HEADER, HeaderValue::from_static("true"));
+    }
+    response
+}
+
+#[cfg(test)]
+mod tests {
+    use std::{thread, time::Duration};
+
+    use axum::{http::StatusCode, response::IntoResponse};
+
+    use super::{IdempotencyStore, Reservation, is_stream_request, store_response};
+
+    #[test]
+    fn reserve_returns_same_slot_for_same_body_and_conflict_for_different_body() {
+        let store = IdempotencyStore::new(60, 1_024);
+
+        let Reservation::Slot(first) = store.reserve("route:key", 1) else {
+            panic!("first reservation must create a slot");
+        };
+        let Reservation::Slot(second) = store.reserve("route:key", 1) else {
+            panic!("same body must reuse the slot");
+        };
+        assert!(std::sync::Arc::ptr_eq(&first, &second));
+        assert!(matches!(store.reserve("route:key", 2), Reservation::Conflict));
+
+        store.release("route:key", &first);
+        assert!(matches!(store.reserve("route:key", 2), Reservation::Slot(_)));
+    }
+
+    #[test]
+    fn reserve_evicts_oldest_key_at_capacity() {
+        let store = IdempotencyStore::with_max_entries(60, 1_024, 2);
+
+        for scope in ["route:a", "route:b", "route:c"] {
+            let Reservation::Slot(_) = store.reserve(scope, 1) else {
+                panic!("{scope} must reserve");
+            };
+        }
+
+        let entries = store.entries.lock().expect("lock must succeed");
+        assert_eq!(entries.by_scope.len(), 2);
+        assert_eq!(entries.order.len(), 2);
+        assert!(!entries.by_scope.contains_key("route:a"), "oldest key must be evicted");
+    }
+
+    #[test]
+    fn reserve_keeps_a_key_reserved_again_after_release() {
+        let store = IdempotencyStore::with_max_entries(60, 1_024, 2);
+
+        let Reservation::Slot(first) = store.reserve("route:a", 1) else {
+            panic!("first key must reserve");
+        };
+        store.release("route:a", &first);
+        thread::sleep(Duration::from_millis(2));
+        let Reservation::Slot(_) = store.reserve("route:a", 1) else {
+            panic!("released key must reserve again");
+        };
+        let Reservation::Slot(_) = store.reserve("route:b", 1) else {
+            panic!("second key must reserve");
+        };
+
+        let entries = store.entries.lock().expect("lock must succeed");
+        assert!(entries.by_scope.contains_key("route:a"), "stale queue entry must not evict");
+        assert!(entries.by_scope.contains_key("route:b"));
+    }
+
+    #[tokio::test]
+    async fn store_response_rejects_bodies_over_the_limit() {
+        let stored = store_response("x".repeat(16).into_response(), 8, "req-1").await;
+        assert_eq!(stored.status, StatusCode::BAD_GATEWAY);
+
+        let stored = store_response("x".repeat(8).into_response(), 8, "req-1").await;
+        assert_eq!(stored.status, StatusCode::OK);
+        assert_eq!(stored.body.len(), 8);
+    }
+
+    #[test]
+    fn is_stream_request_reads_stream_flag() {
+        assert!(is_stream_request(br#"{"model":"m","input":"hi","stream":true}"#));
+        assert!(!is_stream_request(br#"{"model":"m","input":"hi"}"#));
+        assert!(!is_stream_request(b"not json"));
+    }
+}
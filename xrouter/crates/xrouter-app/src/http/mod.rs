@@ -1,4 +1,12 @@
 pub mod auth;
+pub mod circuit_breaker;
+pub mod cors;
+pub mod deprecation;
 pub mod docs;
+pub mod dry_run;
+pub mod endpoint_switch;
 pub mod errors;
+pub mod idempotency;
+pub mod prefill;
+pub mod request_id;
 pub mod routes;
@@ -0,0 +1,43 @@
+use tracing::{debug, info};
+use xrouter_clients_openai::protocol::{emulate_assistant_prefill, trailing_assistant_prefill};
+use xrouter_contracts::ResponsesRequest;
+use xrouter_core::{CoreError, ExecutionEngine};
+
+use crate::config::AssistantPrefillPolicy;
+
+pub(crate) fn apply_assistant_prefill_policy(
+    policy: AssistantPrefillPolicy,
+    engine: &ExecutionEngine,
+    provider: &str,
+    route: &str,
+    request: &mut ResponsesRequest,
+) -> Result<(), CoreError> {
+    if engine.supports_assistant_prefill() || trailing_assistant_prefill(&request.input).is_none() {
+        return Ok(());
+    }
+
+    match policy {
+        AssistantPrefillPolicy::Emulate => {
+            if let Some(input) = emulate_assistant_prefill(&request.input) {
+                debug!(
+                    event = "http.assistant_prefill.emulated",
+                    route = route,
+                    provider = provider
+                );
+                request.input = input;
+            }
+            Ok(())
+        }
+        AssistantPrefillPolicy::Reject => {
+            info!(
+                event = "http.assistant_prefill.rejected",
+                route = route,
+                provider = provider,
+                reason = "provider_not_supported"
+            );
+            Err(CoreError::Validation(format!(
+                "assistant prefill is not supported for provider: {provider}"
+            )))
+        }
+    }
+}
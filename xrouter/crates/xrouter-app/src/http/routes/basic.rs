@@ -16,8 +16,11 @@ use crate::{
     responses((status = 200, description = "Service health", body = HealthResponse)),
     tag = "xrouter-app"
 )]
-pub(crate) async fn get_health() -> Json<HealthResponse> {
-    Json(HealthResponse { status: "healthy".to_string() })
+pub(crate) async fn get_health(State(state): State<AppState>) -> Json<HealthResponse> {
+    Json(HealthResponse {
+        status: "healthy".to_string(),
+        disabled_endpoints: state.endpoint_switches.disabled_classes(),
+    })
 }
 
 #[utoipa::path(
@@ -33,11 +36,17 @@ pub(crate) async fn get_compatible_models(
     let data = state
         .models
         .iter()
-        .map(|m| CompatibleModelEntry {
-            id: synthesize_model_id(&m.provider, &m.id),
-            object: "model".to_string(),
-            created: 1_710_979_200,
-            owned_by: m.provider.clone(),
+        .map(|m| {
+            let id = synthesize_model_id(&m.provider, &m.id);
+            let deprecation = state.model_deprecations.get(&id);
+            CompatibleModelEntry {
+                object: "model".to_string(),
+                created: 1_710_979_200,
+                owned_by: m.provider.clone(),
+                deprecated_at: deprecation.map(|entry| entry.deprecated_at.clone()),
+                replacement: deprecation.and_then(|entry| entry.replacement.clone()),
+                id,
+            }
         })
         .collect::<Vec<_>>();
     info!(event = "http.models.served", route = "/v1/models", model_count = data.len());
@@ -66,25 +75,31 @@ pub(crate) async fn get_xrouter_models(
     let data = state
         .models
         .iter()
-        .map(|m| XrouterModelEntry {
-            id: synthesize_model_id(&m.provider, &m.id),
-            name: synthesize_model_id(&m.provider, &m.id),
-            description: m.description.clone(),
-            context_length: m.context_length,
-            architecture: ModelArchitecture {
-                tokenizer: m.tokenizer.clone(),
-                instruct_type: m.instruct_type.clone(),
-                modality: m.modality.clone(),
-            },
-            top_provider: ModelTopProvider {
-                context_length: m.top_provider_context_length,
-                max_completion_tokens: m.max_completion_tokens,
-                is_moderated: m.is_moderated,
-            },
-            per_request_limits: ModelPerRequestLimits {
-                prompt_tokens: None,
-                completion_tokens: Some(m.max_completion_tokens),
-            },
+        .map(|m| {
+            let id = synthesize_model_id(&m.provider, &m.id);
+            let deprecation = state.model_deprecations.get(&id);
+            XrouterModelEntry {
+                name: id.clone(),
+                description: m.description.clone(),
+                context_length: m.context_length,
+                architecture: ModelArchitecture {
+                    tokenizer: m.tokenizer.clone(),
+                    instruct_type: m.instruct_type.clone(),
+                    modality: m.modality.clone(),
+                },
+                top_provider: ModelTopProvider {
+                    context_length: m.top_provider_context_length,
+                    max_completion_tokens: m.max_completion_tokens,
+                    is_moderated: m.is_moderated,
+                },
+                per_request_limits: ModelPerRequestLimits {
+                    prompt_tokens: None,
+                    completion_tokens: Some(m.max_completion_tokens),
+                },
+                deprecated_at: deprecation.map(|entry| entry.deprecated_at.clone()),
+                replacement: deprecation.and_then(|entry| entry.replacement.clone()),
+                id,
+            }
         })
         .collect::<Vec<_>>();
     info!(event = "http.models.served", route = "/api/v1/models", model_count = data.len());
@@ -4,7 +4,7 @@ use async_trait::async_trait;
 use axum::{
     Json,
     body::Bytes,
-    extract::{MatchedPath, State},
+    extract::{MatchedPath, State, rejection::BytesRejection},
     http::HeaderMap,
     response::{IntoResponse, Response, Sse, sse::Event},
 };
@@ -22,8 +22,18 @@ use xrouter_contracts::{
 use xrouter_core::{CoreError, ExecutionEngine, ResponseEventSink, synthesize_model_id};
 
 use crate::{
-    AppState, http::auth::resolve_byok_bearer, http::docs::ErrorResponse,
-    http::errors::error_response,
+    AppState,
+    http::auth::resolve_byok_bearer,
+    http::circuit_breaker::CircuitBreakers,
+    http::deprecation::check_model_deprecation,
+    http::docs::ErrorResponse,
+    http::dry_run::{dry_run_response, is_dry_run},
+    http::errors::{
+        error_body_for, error_response, invalid_request_body_response,
+        request_body_rejected_response,
+    },
+    http::prefill::apply_assistant_prefill_policy,
+    http::request_id::RequestId,
 };
 
 struct AxumResponseEventSink {
@@ -39,6 +49,9 @@ impl ResponseEventSink for AxumResponseEventSink {
 
 fn spawn_engine_stream(
     engine: Arc<ExecutionEngine>,
+    circuit_breakers: Arc<CircuitBreakers>,
+    public_model_id: String,
+    request_id: RequestId,
     request: ResponsesRequest,
     auth_bearer: Option<String>,
     forward_headers: Vec<(String, String)>,
@@ -46,8 +59,17 @@ fn spawn_engine_stream(
     let (tx, rx) = mpsc::channel(32);
     let sink: Arc<dyn ResponseEventSink> = Arc::new(AxumResponseEventSink { sender: tx });
     tokio::spawn(async move {
-        let _ =
-            engine.execute_stream_to_sink(request, None, auth_bearer, forward_headers, sink).await;
+        let result = engine
+            .execute_stream_to_sink(
+                request,
+                Some(request_id.as_str().to_string()),
+                None,
+                auth_bearer,
+                forward_headers,
+                sink,
+            )
+            .await;
+        circuit_breakers.record(&public_model_id, result.err().as_ref());
     });
     ReceiverStream::new(rx)
 }
@@ -58,7 +80,16 @@ fn spawn_engine_stream(
     request_body = ResponsesRequest,
     responses(
         (status = 200, description = "Responses API result", body = ResponsesResponse),
-        (status = 400, description = "Validation or provider error", body = ErrorResponse)
+        (status = 400, description = "Validation error", body = ErrorResponse),
+        (status = 401, description = "Missing BYOK bearer token", body = ErrorResponse),
+        (status = 404, description = "Model not found", body = ErrorResponse),
+        (status = 409, description = "Idempotency key reused with a different body", body = ErrorResponse),
+        (status = 410, description = "Model is past its deprecation date", body = ErrorResponse),
+        (status = 413, description = "Request body too large", body = ErrorResponse),
+        (status = 422, description = "Invalid request body", body = ErrorResponse),
+        (status = 429, description = "Provider in-flight limit reached", body = ErrorResponse),
+        (status = 502, description = "Upstream provider failure", body = ErrorResponse),
+        (status = 503, description = "Endpoint disabled by the operator or provider circuit open", body = ErrorResponse)
     ),
     tag = "xrouter-app"
 )]
@@ -66,7 +97,8 @@ pub(crate) async fn post_responses(
     State(state): State<AppState>,
     matched_path: Option<MatchedPath>,
     headers: HeaderMap,
-    request_body: Bytes,
+    request_id: RequestId,
+    request_body: Result<Bytes, BytesRejection>,
 ) -> Response {
     let started_at = Instant::now();
     let route = matched_path.as_ref().map_or("/api/v1/responses", MatchedPath::as_str).to_string();
@@ -75,7 +107,7 @@ pub(crate) async fn post_responses(
         otel.name = "http.request",
         otel.kind = "server",
         openinference.span.kind = "CHAIN",
-        request.id = field::Empty,
+        request.id = request_id.as_str(),
         response.id = field::Empty,
         route = %route,
         model = field::Empty,
@@ -86,6 +118,17 @@ pub(crate) async fn post_responses(
     );
     attach_parent_context(&request_span, &headers);
     let _request_span_guard = request_span.enter();
+    let request_body = match request_body {
+        Ok(request_body) => request_body,
+        Err(rejection) => {
+            return request_body_rejected_response(
+                &route,
+                &headers,
+                rejection,
+                request_id.as_str(),
+            );
+        }
+    };
     let mut request: ResponsesRequest = match serde_json::from_slice(&request_body) {
         Ok(request) => request,
         Err(err) => {
@@ -100,13 +143,12 @@ pub(crate) async fn post_responses(
                 route = route,
                 payload_preview = %preview_request_body(&request_body)
             );
-            return (
-                axum::http::StatusCode::UNPROCESSABLE_ENTITY,
-                Json(ErrorResponse { error: "invalid request body".to_string() }),
-            )
-                .into_response();
+            return invalid_request_body_response(request_id.as_str());
         }
     };
+    if is_dry_run(&headers) {
+        return dry_run_response(&state, &headers, route.as_str(), &request_id, request);
+    }
     let normalized_input = request.input.to_canonical_text();
     let request_model = request.model.clone();
     let provider = state.resolve_provider_key(&request.model);
@@ -120,8 +162,16 @@ pub(crate) async fn post_responses(
         route.as_str(),
     ) {
         Ok(token) => token,
-        Err(err) => return error_response(err),
+        Err(err) => return error_response(err, request_id.as_str()),
     };
+    if let Err(err) = check_model_deprecation(
+        &state.model_deprecations,
+        &public_model_id,
+        &headers,
+        route.as_str(),
+    ) {
+        return error_response(err, request_id.as_str());
+    }
     request_span.record("model", public_model_id.as_str());
     request_span.record("provider", provider.as_str());
     request_span.record("stream", request.stream);
@@ -155,9 +205,21 @@ pub(crate) async fn post_responses(
                 duration_ms = started_at.elapsed().as_millis() as u64,
                 error = %err
             );
-            return error_response(err);
+            return error_response(err, request_id.as_str());
         }
     };
+    if let Err(err) = apply_assistant_prefill_policy(
+        state.assistant_prefill,
+        &engine,
+        provider.as_str(),
+        route.as_str(),
+        &mut request,
+    ) {
+        return error_response(err, request_id.as_str());
+    }
+    if let Err(err) = state.circuit_breakers.try_acquire(&public_model_id, route.as_str()) {
+        return error_response(err, request_id.as_str());
+    }
 
     if request.stream {
         let stream_route = route.clone();
@@ -205,6 +267,9 @@ pub(crate) async fn post_responses(
 
         let stream = spawn_engine_stream(
             engine.clone(),
+            state.circuit_breakers.clone(),
+            public_model_id.clone(),
+            request_id.clone(),
             request,
             auth_bearer.clone(),
             forward_headers.clone(),
@@ -212,9 +277,8 @@ pub(crate) async fn post_responses(
         .flat_map(move |event| {
             let mut events = Vec::<Result<Event, Infallible>>::new();
             if let Ok(ref mapped) = event {
-                if let Some(request_id) = response_event_request_id(mapped) {
-                    stream_request_span.record("request.id", request_id);
-                    stream_request_span.record("response.id", request_id);
+                if let Some(event_id) = response_event_request_id(mapped) {
+                    stream_request_span.record("response.id", event_id);
                 }
                 record_response_event_classification(
                     stream_route.as_str(),
@@ -257,6 +321,7 @@ pub(crate) async fn post_responses(
                         reasoning_chars = reasoning.as_ref().map(|it| it.len()).unwrap_or(0),
                         input_tokens = usage.input_tokens,
                         output_tokens = usage.output_tokens,
+                        reasoning_tokens = usage.output_tokens_details.reasoning_tokens,
                         total_tokens = usage.total_tokens,
                         duration_ms = started_at.elapsed().as_millis() as u64
                     );
@@ -281,7 +346,8 @@ pub(crate) async fn post_responses(
                                 "usage": {
                                     "input_tokens": usage.input_tokens,
                                     "output_tokens": usage.output_tokens,
-                                    "total_tokens": usage.total_tokens
+                                    "total_tokens": usage.total_tokens,
+                                    "output_tokens_details": usage.output_tokens_details
                                 }
                             }
                         })
@@ -298,9 +364,13 @@ pub(crate) async fn post_responses(
                         duration_ms = started_at.elapsed().as_millis() as u64,
                         error = %message
                     );
-                    events.push(Ok(Event::default()
-                        .event("response.error")
-                        .data(json!({"type": "response.error", "error": message}).to_string())));
+                    events.push(Ok(Event::default().event("response.error").data(
+                        json!({
+                            "type": "response.error",
+                            "error": error_body_for(&CoreError::Provider(message))
+                        })
+                        .to_string(),
+                    )));
                 }
                 Err(error) => {
                     stream_request_span.set_status(Status::error(error.to_string()));
@@ -313,7 +383,8 @@ pub(crate) async fn post_responses(
                         error = %error
                     );
                     events.push(Ok(Event::default().event("response.error").data(
-                        json!({"type": "response.error", "error": error.to_string()}).to_string(),
+                        json!({"type": "response.error", "error": error_body_for(&error)})
+                            .to_string(),
                     )));
                 }
             }
@@ -339,10 +410,12 @@ pub(crate) async fn post_responses(
         return Sse::new(full_stream).into_response();
     }
 
-    match run_responses_request(engine, request, auth_bearer, forward_headers).await {
+    let result =
+        run_responses_request(engine, &request_id, request, auth_bearer, forward_headers).await;
+    state.circuit_breakers.record(&public_model_id, result.as_ref().err());
+    match result {
         Ok(mut resp) => {
             resp.id = ensure_id_prefix(&resp.id, "resp_");
-            request_span.record("request.id", resp.id.as_str());
             request_span.record("response.id", resp.id.as_str());
             let response_text = extract_message_text_from_output(&resp.output);
             request_span.record("output.value", truncate_attr_value(&response_text, 512));
@@ -365,6 +438,7 @@ pub(crate) async fn post_responses(
                 reasoning_chars = reasoning.as_ref().map(|it| it.len()).unwrap_or(0),
                 input_tokens = resp.usage.input_tokens,
                 output_tokens = resp.usage.output_tokens,
+                reasoning_tokens = resp.usage.output_tokens_details.reasoning_tokens,
                 total_tokens = resp.usage.total_tokens,
                 duration_ms = started_at.elapsed().as_millis() as u64
             );
@@ -380,7 +454,7 @@ pub(crate) async fn post_responses(
                 duration_ms = started_at.elapsed().as_millis() as u64,
                 error = %err
             );
-            error_response(err)
+            error_response(err, request_id.as_str())
         }
     }
 }
@@ -391,14 +465,24 @@ pub(crate) async fn post_responses(
     request_body = ChatCompletionsRequest,
     responses(
         (status = 200, description = "Chat Completions API result", body = ChatCompletionsResponse),
-        (status = 400, description = "Validation or provider error", body = ErrorResponse)
+        (status = 400, description = "Validation error", body = ErrorResponse),
+        (status = 401, description = "Missing BYOK bearer token", body = ErrorResponse),
+        (status = 404, description = "Model not found", body = ErrorResponse),
+        (status = 409, description = "Idempotency key reused with a different body", body = ErrorResponse),
+        (status = 410, description = "Model is past its deprecation date", body = ErrorResponse),
+        (status = 413, description = "Request body too large", body = ErrorResponse),
+        (status = 422, description = "Invalid request body", body = ErrorResponse),
+        (status = 429, description = "Provider in-flight limit reached", body = ErrorResponse),
+        (status = 502, description = "Upstream provider failure", body = ErrorResponse),
+        (status = 503, description = "Endpoint disabled by the operator or provider circuit open", body = ErrorResponse)
     ),
     tag = "xrouter-app"
 )]
 pub(crate) async fn post_chat_completions(
     State(state): State<AppState>,
     headers: HeaderMap,
-    Json(request): Json<ChatCompletionsRequest>,
+    request_id: RequestId,
+    request_body: Result<Bytes, BytesRejection>,
 ) -> Response {
     let started_at = Instant::now();
     let request_span = info_span!(
@@ -406,7 +490,7 @@ pub(crate) async fn post_chat_completions(
         otel.name = "http.request",
         otel.kind = "server",
         openinference.span.kind = "CHAIN",
-        request.id = field::Empty,
+        request.id = request_id.as_str(),
         response.id = field::Empty,
         route = "/api/v1/chat/completions",
         model = field::Empty,
@@ -417,13 +501,60 @@ pub(crate) async fn post_chat_completions(
     );
     attach_parent_context(&request_span, &headers);
     let _request_span_guard = request_span.enter();
+    let request_body = match request_body {
+        Ok(request_body) => request_body,
+        Err(rejection) => {
+            return request_body_rejected_response(
+                "/api/v1/chat/completions",
+                &headers,
+                rejection,
+                request_id.as_str(),
+            );
+        }
+    };
+    let request: ChatCompletionsRequest = match serde_json::from_slice(&request_body) {
+        Ok(request) => request,
+        Err(err) => {
+            info!(
+                event = "http.request.invalid_json",
+                route = "/api/v1/chat/completions",
+                body_bytes = request_body.len(),
+                error = %err
+            );
+            debug!(
+                event = "http.request.invalid_json.payload",
+                route = "/api/v1/chat/completions",
+                payload_preview = %preview_request_body(&request_body)
+            );
+            return invalid_request_body_response(request_id.as_str());
+        }
+    };
+    let mut core_request = match request.clone().into_responses_request() {
+        Ok(core_request) => core_request,
+        Err(message) => {
+            info!(
+                event = "http.request.invalid_messages",
+                route = "/api/v1/chat/completions",
+                error = %message
+            );
+            return error_response(CoreError::Validation(message), request_id.as_str());
+        }
+    };
+    if is_dry_run(&headers) {
+        return dry_run_response(
+            &state,
+            &headers,
+            "/api/v1/chat/completions",
+            &request_id,
+            core_request,
+        );
+    }
     let request_payload = request
         .messages
         .iter()
         .map(|message| format!("{}:{}", message.role, message.content))
         .collect::<Vec<_>>()
         .join("\n");
-    let mut core_request = request.clone().into_responses_request();
     let request_model = core_request.model.clone();
     let provider = state.resolve_provider_key(&core_request.model);
     let provider_model = state.resolve_provider_model_id(&core_request.model);
@@ -436,8 +567,16 @@ pub(crate) async fn post_chat_completions(
         "/api/v1/chat/completions",
     ) {
         Ok(token) => token,
-        Err(err) => return error_response(err),
+        Err(err) => return error_response(err, request_id.as_str()),
     };
+    if let Err(err) = check_model_deprecation(
+        &state.model_deprecations,
+        &public_model_id,
+        &headers,
+        "/api/v1/chat/completions",
+    ) {
+        return error_response(err, request_id.as_str());
+    }
     request_span.record("model", public_model_id.as_str());
     request_span.record("provider", provider.as_str());
     request_span.record("stream", request.stream);
@@ -469,9 +608,23 @@ pub(crate) async fn post_chat_completions(
                 duration_ms = started_at.elapsed().as_millis() as u64,
                 error = %err
             );
-            return error_response(err);
+            return error_response(err, request_id.as_str());
         }
     };
+    if let Err(err) = apply_assistant_prefill_policy(
+        state.assistant_prefill,
+        &engine,
+        provider.as_str(),
+        "/api/v1/chat/completions",
+        &mut core_request,
+    ) {
+        return error_response(err, request_id.as_str());
+    }
+    if let Err(err) =
+        state.circuit_breakers.try_acquire(&public_model_id, "/api/v1/chat/completions")
+    {
+        return error_response(err, request_id.as_str());
+    }
 
     if request.stream {
         let chat_completion_id = new_prefixed_id("chatcmpl_");
@@ -487,15 +640,17 @@ pub(crate) async fn post_chat_completions(
         let stream_started_at = started_at;
         let stream = spawn_engine_stream(
                 engine.clone(),
+                state.circuit_breakers.clone(),
+                public_model_id.clone(),
+                request_id.clone(),
                 core_request,
                 auth_bearer.clone(),
                 forward_headers.clone(),
             ).map(
                 move |evt| {
                     if let Ok(ref mapped) = evt {
-                        if let Some(request_id) = response_event_request_id(mapped) {
-                            stream_request_span.record("request.id", request_id);
-                            stream_request_span.record("response.id", request_id);
+                        if let Some(event_id) = response_event_request_id(mapped) {
+                            stream_request_span.record("response.id", event_id);
                         }
                         record_response_event_classification(
                             stream_route.as_str(),
@@ -579,8 +734,11 @@ pub(crate) async fn post_chat_completions(
                                 error = %message
                             );
                             Ok(Event::default().data(
-                                json!({"id": chat_completion_id.clone(), "error": message})
-                                    .to_string(),
+                                json!({
+                                    "id": chat_completion_id.clone(),
+                                    "error": error_body_for(&CoreError::Provider(message))
+                                })
+                                .to_string(),
                             ))
                         }
                         Err(error) => {
@@ -593,8 +751,11 @@ pub(crate) async fn post_chat_completions(
                                 error = %error
                             );
                             Ok(Event::default().data(
-                                json!({"id": chat_completion_id.clone(), "error": error.to_string()})
-                                    .to_string(),
+                                json!({
+                                    "id": chat_completion_id.clone(),
+                                    "error": error_body_for(&error)
+                                })
+                                .to_string(),
                             ))
                         }
                     }
@@ -606,10 +767,13 @@ pub(crate) async fn post_chat_completions(
         return Sse::new(stream.chain(done)).into_response();
     }
 
-    match run_responses_request(engine, core_request, auth_bearer, forward_headers).await {
+    let result =
+        run_responses_request(engine, &request_id, core_request, auth_bearer, forward_headers)
+            .await;
+    state.circuit_breakers.record(&public_model_id, result.as_ref().err());
+    match result {
         Ok(mut resp) => {
             resp.id = ensure_id_prefix(&resp.id, "resp_");
-            request_span.record("request.id", resp.id.as_str());
             request_span.record("response.id", resp.id.as_str());
             let response_text = extract_message_text_from_output(&resp.output);
             request_span.record("output.value", truncate_attr_value(&response_text, 512));
@@ -632,6 +796,7 @@ pub(crate) async fn post_chat_completions(
                 reasoning_chars = reasoning.as_ref().map(|it| it.len()).unwrap_or(0),
                 input_tokens = resp.usage.input_tokens,
                 output_tokens = resp.usage.output_tokens,
+                reasoning_tokens = resp.usage.output_tokens_details.reasoning_tokens,
                 total_tokens = resp.usage.total_tokens,
                 duration_ms = started_at.elapsed().as_millis() as u64
             );
@@ -649,18 +814,26 @@ pub(crate) async fn post_chat_completions(
                 duration_ms = started_at.elapsed().as_millis() as u64,
                 error = %err
             );
-            error_response(err)
+            error_response(err, request_id.as_str())
         }
     }
 }
 
 async fn run_responses_request(
     engine: Arc<ExecutionEngine>,
+    request_id: &RequestId,
     request: ResponsesRequest,
     auth_bearer: Option<String>,
     forward_headers: Vec<(String, String)>,
 ) -> Result<ResponsesResponse, CoreError> {
-    engine.execute_with_auth(request, auth_bearer, forward_headers).await
+    engine
+        .execute_with_auth(
+            request,
+            Some(request_id.as_str().to_string()),
+            auth_bearer,
+            forward_headers,
+        )
+        .await
 }
 
 fn extract_forward_headers(headers: &HeaderMap, provider: &str) -> Vec<(String, String)> {
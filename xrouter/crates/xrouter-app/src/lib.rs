@@ -10,7 +10,11 @@ pub use startup::app_builder::AppBuilder;
 mod tests {
     use std::{
         collections::{BTreeMap, HashMap},
-        sync::{Arc, Mutex},
+        sync::{
+            Arc, Mutex,
+            atomic::{AtomicUsize, Ordering},
+        },
+        time::Duration,
     };
 
     use async_trait::async_trait;
@@ -30,7 +34,7 @@ mod tests {
     };
     use xrouter_core::{
         CoreError, ExecutionEngine, ModelDescriptor, ProviderClient, ProviderGenerateRequest,
-        ProviderOutcome,
+        ProviderGenerateStreamRequest, ProviderOutcome,
     };
 
     #[derive(Debug)]
@@ -56,6 +60,122 @@ mod tests {
             Ok(ProviderOutcome {
                 chunks: vec!["ok".to_string()],
                 output_tokens: 1,
+                reasoning_tokens: 0,
+                reasoning: None,
+                reasoning_details: None,
+                tool_calls: None,
+                emitted_live: false,
+            })
+        }
+    }
+
+    struct RequestIdCaptureProvider {
+        seen_request_id: Arc<Mutex<Option<String>>>,
+        fail: bool,
+    }
+
+    #[async_trait]
+    impl ProviderClient for RequestIdCaptureProvider {
+        async fn generate(
+            &self,
+            _request: ProviderGenerateRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            unreachable!("engine always calls generate_stream")
+        }
+
+        async fn generate_stream(
+            &self,
+            request: ProviderGenerateStreamRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            *self.seen_request_id.lock().expect("lock must succeed") =
+                Some(request.request_id.to_string());
+            if self.fail {
+                return Err(CoreError::Provider("provider failed".to_string()));
+            }
+            Ok(ProviderOutcome {
+                chunks: vec!["ok".to_string()],
+                output_tokens: 1,
+                reasoning_tokens: 0,
+                reasoning: None,
+                reasoning_details: None,
+                tool_calls: None,
+                emitted_live: false,
+            })
+        }
+    }
+
+    struct CountingProvider {
+        calls: Arc<AtomicUsize>,
+        fail: bool,
+    }
+
+    #[async_trait]
+    impl ProviderClient for CountingProvider {
+        async fn generate(
+            &self,
+            _request: ProviderGenerateRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            self.calls.fetch_add(1, Ordering::SeqCst);
+            tokio::time::sleep(Duration::from_millis(50)).await;
+            if self.fail {
+                return Err(CoreError::Provider("provider request failed: boom".to_string()));
+            }
+            Ok(ProviderOutcome {
+                chunks: vec!["ok".to_string()],
+                output_tokens: 1,
+                reasoning_tokens: 0,
+                reasoning: None,
+                reasoning_details: None,
+                tool_calls: None,
+                emitted_live: false,
+            })
+        }
+    }
+
+    struct UpstreamRejectingProvider;
+
+    #[async_trait]
+    impl ProviderClient for UpstreamRejectingProvider {
+        async fn generate(
+            &self,
+            _request: ProviderGenerateRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            Err(CoreError::UpstreamStatus {
+                status: 400,
+                message: "provider returned error status: 400 (Bad Request)".to_string(),
+                error: Some(json!({
+                    "message": "max_tokens is too large",
+                    "type": "invalid_request_error",
+                    "param": "max_tokens",
+                    "code": null
+                })),
+            })
+        }
+    }
+
+    struct PrefillContinuationProvider {
+        seen_input: Arc<Mutex<Option<String>>>,
+    }
+
+    #[async_trait]
+    impl ProviderClient for PrefillContinuationProvider {
+        async fn generate(
+            &self,
+            _request: ProviderGenerateRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            unreachable!("engine always calls generate_stream")
+        }
+
+        async fn generate_stream(
+            &self,
+            request: ProviderGenerateStreamRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            *self.seen_input.lock().expect("lock must succeed") =
+                Some(request.request.input.to_canonical_text());
+            Ok(ProviderOutcome {
+                chunks: vec![" three".to_string(), " four".to_string()],
+                output_tokens: 2,
+                reasoning_tokens: 0,
                 reasoning: None,
                 reasoning_details: None,
                 tool_calls: None,
@@ -67,11 +187,12 @@ mod tests {
     fn build_openrouter_header_capture_app(
         seen_headers: Arc<Mutex<Vec<(String, String)>>>,
     ) -> axum::Router {
+        build_openrouter_app(Arc::new(HeaderCaptureProvider { seen_headers }))
+    }
+
+    fn build_openrouter_app(provider: Arc<dyn ProviderClient>) -> axum::Router {
         let mut engines = HashMap::new();
-        engines.insert(
-            "openrouter".to_string(),
-            Arc::new(ExecutionEngine::new(Arc::new(HeaderCaptureProvider { seen_headers }))),
-        );
+        engines.insert("openrouter".to_string(), Arc::new(ExecutionEngine::new(provider)));
         let state = AppState::from_parts(
             false,
             false,
@@ -162,6 +283,8 @@ mod tests {
             api_key: None,
             base_url: Some("http://127.0.0.1:0".to_string()),
             project: None,
+            headers: Vec::new(),
+            suppress_bearer: false,
         };
         let models = fetch_openrouter_models(&provider, &["openai/gpt-5.2".to_string()], 1);
         assert!(models.is_none());
@@ -272,8 +395,14 @@ mod tests {
             return format!("json.status={status}");
         }
 
-        if let Some(error) = obj.get("error").and_then(Value::as_str) {
-            return format!("json.error={error}");
+        if let Some(error) = obj.get("error").and_then(Value::as_object) {
+            let field = |name: &str| error.get(name).and_then(Value::as_str).unwrap_or("<none>");
+            return format!(
+                "json.error.type={}\njson.error.code={}\njson.error.message={}",
+                field("type"),
+                field("code"),
+                field("message")
+            );
         }
 
         if let Some(data) = obj.get("data").and_then(Value::as_array) {
@@ -452,7 +581,9 @@ body={"model":"gpt-4.1-mini","input":"","stream":false}
 "#,
                 r#"
 status=400
-json.error=validation failed: input must not be empty
+json.error.type=invalid_request_error
+json.error.code=invalid_request
+json.error.message=validation failed: input must not be empty
 "#,
             ),
             (
@@ -520,7 +651,9 @@ body={"model":"deepseek/deepseek-chat","input":[1],"stream":false}
 "#,
                 r#"
 status=422
-json.error=invalid request body
+json.error.type=invalid_request_error
+json.error.code=invalid_request_body
+json.error.message=invalid request body
 "#,
             ),
             (
@@ -873,7 +1006,7 @@ json.first_id=<none>
     }
 
     #[tokio::test]
-    async fn responses_non_stream_surfaces_provider_failure_as_400() {
+    async fn responses_non_stream_surfaces_provider_failure_as_502() {
         let app = build_router(test_app_state(false));
         let response = app
             .oneshot(
@@ -889,15 +1022,74 @@ json.first_id=<none>
             .await
             .expect("request must complete");
 
-        assert_eq!(response.status(), StatusCode::BAD_REQUEST);
+        assert_eq!(response.status(), StatusCode::BAD_GATEWAY);
+        assert!(response.headers().contains_key("x-request-id"));
         let body = to_bytes(response.into_body(), usize::MAX)
             .await
             .expect("response body read must succeed");
         let payload: Value =
             serde_json::from_slice(&body).expect("response body must be valid json");
         assert_eq!(
-            payload.get("error").and_then(Value::as_str),
-            Some("provider error: provider failed")
+            payload,
+            json!({
+                "error": {
+                    "message": "provider error: provider failed",
+                    "type": "api_error",
+                    "param": null,
+                    "code": "provider_error"
+                }
+            })
+        );
+    }
+
+    async fn post_openrouter_responses(app: axum::Router) -> (String, Value) {
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/responses")
+                    .header("content-type", "application/json")
+                    .body(Body::from(r#"{"model":"openai/gpt-5-mini","input":"hello"}"#))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        let request_id = response
+            .headers()
+            .get("x-request-id")
+            .and_then(|value| value.to_str().ok())
+            .expect("x-request-id header must be present")
+            .to_string();
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        (request_id, serde_json::from_slice(&body).expect("response body must be valid json"))
+    }
+
+    #[tokio::test]
+    async fn request_id_header_matches_engine_request_id() {
+        let seen_request_id = Arc::new(Mutex::new(None));
+        let app = build_openrouter_app(Arc::new(RequestIdCaptureProvider {
+            seen_request_id: seen_request_id.clone(),
+            fail: false,
+        }));
+        let (request_id, payload) = post_openrouter_responses(app).await;
+        assert_eq!(
+            seen_request_id.lock().expect("lock must succeed").as_deref(),
+            Some(request_id.as_str())
+        );
+        assert_eq!(payload["id"], format!("resp_{request_id}"));
+
+        let seen_request_id = Arc::new(Mutex::new(None));
+        let app = build_openrouter_app(Arc::new(RequestIdCaptureProvider {
+            seen_request_id: seen_request_id.clone(),
+            fail: true,
+        }));
+        let (request_id, payload) = post_openrouter_responses(app).await;
+        assert_eq!(payload.pointer("/error/code").and_then(Value::as_str), Some("provider_error"));
+        assert_eq!(
+            seen_request_id.lock().expect("lock must succeed").as_deref(),
+            Some(request_id.as_str())
         );
     }
 
@@ -925,13 +1117,52 @@ json.first_id=<none>
         let payload = String::from_utf8_lossy(&body);
         assert!(payload.contains("event: response.created"));
         assert!(payload.contains("event: response.error"));
-        assert!(payload.contains("\"error\":\"provider error: provider failed\""));
+        assert!(payload.contains("\"message\":\"provider error: provider failed\""));
+        assert!(payload.contains("\"code\":\"provider_error\""));
         assert!(
             !payload.contains("event: response.completed"),
             "failed stream must not claim completion"
         );
     }
 
+    #[tokio::test]
+    async fn streams_pass_through_upstream_error_envelope() {
+        let expected = json!({
+            "message": "max_tokens is too large",
+            "type": "invalid_request_error",
+            "param": "max_tokens",
+            "code": null
+        });
+        for (uri, body) in [
+            ("/api/v1/responses", r#"{"model":"openai/gpt-5-mini","input":"hello","stream":true}"#),
+            (
+                "/api/v1/chat/completions",
+                r#"{"model":"openai/gpt-5-mini","messages":[{"role":"user","content":"hello"}],"stream":true}"#,
+            ),
+        ] {
+            let response = build_openrouter_app(Arc::new(UpstreamRejectingProvider))
+                .oneshot(
+                    Request::builder()
+                        .method("POST")
+                        .uri(uri)
+                        .header("content-type", "application/json")
+                        .body(Body::from(body))
+                        .expect("request must build"),
+                )
+                .await
+                .expect("request must complete");
+            let body = to_bytes(response.into_body(), usize::MAX)
+                .await
+                .expect("response body read must succeed");
+            let error = String::from_utf8_lossy(&body)
+                .lines()
+                .filter_map(|line| line.strip_prefix("data: "))
+                .filter_map(|data| serde_json::from_str::<Value>(data).ok())
+                .find_map(|event| event.get("error").cloned());
+            assert_eq!(error, Some(expected.clone()), "stream error for {uri}");
+        }
+    }
+
     #[tokio::test]
     async fn chat_stream_emits_error_chunk_and_done_marker_on_provider_failure() {
         let app = build_router(test_app_state(false));
@@ -954,7 +1185,8 @@ json.first_id=<none>
             .await
             .expect("response body read must succeed");
         let payload = String::from_utf8_lossy(&body);
-        assert!(payload.contains("\"error\":\"provider error: provider failed\""));
+        assert!(payload.contains("\"message\":\"provider error: provider failed\""));
+        assert!(payload.contains("\"type\":\"api_error\""));
         assert!(payload.contains("[DONE]"), "chat stream must still terminate with done marker");
     }
 
@@ -1132,7 +1364,7 @@ json.first_id=<none>
             .await
             .expect("request must complete");
 
-        assert_eq!(response.status(), StatusCode::BAD_REQUEST);
+        assert_eq!(response.status(), StatusCode::UNAUTHORIZED);
     }
 
     #[tokio::test]
@@ -1160,16 +1392,875 @@ json.first_id=<none>
 
     #[test]
     fn error_response_returns_429_for_provider_overload() {
-        let response = error_response(CoreError::Provider(
-            "provider overloaded: max in-flight limit reached for deepseek".to_string(),
-        ));
+        let response = error_response(
+            CoreError::ProviderOverloaded("max in-flight limit reached for deepseek".to_string()),
+            "req-test",
+        );
         assert_eq!(response.status(), StatusCode::TOO_MANY_REQUESTS);
     }
 
     #[test]
-    fn error_response_keeps_400_for_regular_provider_error() {
-        let response =
-            error_response(CoreError::Provider("provider request failed: timeout".to_string()));
-        assert_eq!(response.status(), StatusCode::BAD_REQUEST);
+    fn error_response_sets_retry_after_for_open_circuit() {
+        let response = error_response(
+            CoreError::CircuitOpen {
+                model: "deepseek/deepseek-chat".to_string(),
+                retry_after_seconds: 12,
+            },
+            "req-test",
+        );
+        assert_eq!(response.status(), StatusCode::SERVICE_UNAVAILABLE);
+        assert_eq!(response.headers().get("retry-after").and_then(|v| v.to_str().ok()), Some("12"));
+    }
+
+    #[test]
+    fn error_response_returns_502_for_regular_provider_error() {
+        let response = error_response(
+            CoreError::Provider("provider request failed: timeout".to_string()),
+            "req-test",
+        );
+        assert_eq!(response.status(), StatusCode::BAD_GATEWAY);
+    }
+
+    async fn error_envelope(response: Response) -> (StatusCode, Value) {
+        let status = response.status();
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let payload: Value =
+            serde_json::from_slice(&body).expect("response body must be valid json");
+        (status, payload.get("error").cloned().expect("error envelope must be present"))
+    }
+
+    #[tokio::test]
+    async fn error_response_uses_openai_envelope_for_each_failure_class() {
+        let cases = [
+            (
+                CoreError::Validation("input must not be empty".to_string()),
+                StatusCode::BAD_REQUEST,
+                "invalid_request_error",
+                "invalid_request",
+            ),
+            (
+                CoreError::Validation(
+                    "authorization bearer token is required when XR_BYOK_ENABLED=true".to_string(),
+                ),
+                StatusCode::UNAUTHORIZED,
+                "authentication_error",
+                "missing_authorization",
+            ),
+            (
+                CoreError::Validation("BYOK is not supported for yandex provider".to_string()),
+                StatusCode::BAD_REQUEST,
+                "invalid_request_error",
+                "byok_not_supported",
+            ),
+            (
+                CoreError::Validation("unsupported provider for model: gpt-x".to_string()),
+                StatusCode::NOT_FOUND,
+                "invalid_request_error",
+                "model_not_found",
+            ),
+            (
+                CoreError::ProviderOverloaded(
+                    "max in-flight limit reached for deepseek".to_string(),
+                ),
+                StatusCode::TOO_MANY_REQUESTS,
+                "rate_limit_error",
+                "provider_overloaded",
+            ),
+            (
+                CoreError::ResponseTooLarge("more than 1024 bytes from deepseek".to_string()),
+                StatusCode::BAD_GATEWAY,
+                "api_error",
+                "upstream_response_too_large",
+            ),
+            (
+                CoreError::CircuitOpen {
+                    model: "deepseek/deepseek-chat".to_string(),
+                    retry_after_seconds: 30,
+                },
+                StatusCode::SERVICE_UNAVAILABLE,
+                "api_error",
+                "provider_circuit_open",
+            ),
+            (
+                CoreError::UpstreamStatus {
+                    status: 503,
+                    message: "provider returned error status: 503".to_string(),
+                    error: None,
+                },
+                StatusCode::BAD_GATEWAY,
+                "api_error",
+                "upstream_error",
+            ),
+            (
+                CoreError::UpstreamStatus {
+                    status: 401,
+                    message: "provider returned error status: 401".to_string(),
+                    error: None,
+                },
+                StatusCode::UNAUTHORIZED,
+                "authentication_error",
+                "upstream_error",
+            ),
+        ];
+
+        for (error, expected_status, expected_type, expected_code) in cases {
+            let response = error_response(error, "req-test");
+            assert!(response.headers().contains_key("x-request-id"));
+            let (status, envelope) = error_envelope(response).await;
+            assert_eq!(status, expected_status, "status for {expected_code}");
+            assert_eq!(envelope.get("type").and_then(Value::as_str), Some(expected_type));
+            assert_eq!(envelope.get("code").and_then(Value::as_str), Some(expected_code));
+            assert!(envelope.get("message").and_then(Value::as_str).is_some());
+            assert!(envelope.get("param").is_some(), "param must always be present");
+        }
+    }
+
+    #[tokio::test]
+    async fn error_response_passes_through_openai_shaped_upstream_error() {
+        let response = error_response(
+            CoreError::UpstreamStatus {
+                status: 400,
+                message: "provider returned error status: 400 (Bad Request)".to_string(),
+                error: Some(json!({
+                    "message": "max_tokens is too large",
+                    "type": "invalid_request_error",
+                    "param": "max_tokens",
+                    "code": "context_length_exceeded"
+                })),
+            },
+            "req-test",
+        );
+        let (status, envelope) = error_envelope(response).await;
+        assert_eq!(status, StatusCode::BAD_REQUEST);
+        assert_eq!(
+            envelope,
+            json!({
+                "message": "max_tokens is too large",
+                "type": "invalid_request_error",
+                "param": "max_tokens",
+                "code": "context_length_exceeded"
+            })
+        );
+    }
+
+    #[tokio::test]
+    async fn chat_invalid_json_returns_openai_envelope() {
+        let app = build_router(test_app_state(false));
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/chat/completions")
+                    .header("content-type", "application/json")
+                    .body(Body::from(r#"{"model":"deepseek/deepseek-chat","messages":"hi"}"#))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+
+        let (status, envelope) = error_envelope(response).await;
+        assert_eq!(status, StatusCode::UNPROCESSABLE_ENTITY);
+        assert_eq!(envelope.get("code").and_then(Value::as_str), Some("invalid_request_body"));
+    }
+
+    fn dry_run_request(uri: &str, body: &'static str) -> Request<Body> {
+        Request::builder()
+            .method("POST")
+            .uri(uri)
+            .header("content-type", "application/json")
+            .header("x-dry-run", "true")
+            .body(Body::from(body))
+            .expect("request must build")
+    }
+
+    #[tokio::test]
+    async fn dry_run_reports_stages_without_calling_provider() {
+        let seen_headers =
+            Arc::new(Mutex::new(vec![("untouched".to_string(), "value".to_string())]));
+        let app = build_openrouter_header_capture_app(seen_headers.clone());
+        let response = app
+            .oneshot(dry_run_request(
+                "/api/v1/responses",
+                r#"{"model":"openrouter/openai/gpt-5-mini","input":"hello dry run","stream":true}"#,
+            ))
+            .await
+            .expect("request must complete");
+
+        assert_eq!(response.status(), StatusCode::OK);
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let payload: Value =
+            serde_json::from_slice(&body).expect("response body must be valid json");
+        assert_eq!(
+            payload,
+            json!({
+                "object": "dry_run",
+                "accepted": true,
+                "model": "openrouter/openai/gpt-5-mini",
+                "provider": "openrouter",
+                "stages": [
+                    {"stage": "auth", "status": "ok"},
+                    {"stage": "model_resolution", "status": "ok"},
+                    {"stage": "ingest", "status": "ok"},
+                    {"stage": "tokenize", "status": "ok", "input_tokens": 3}
+                ]
+            })
+        );
+        assert_eq!(
+            seen_headers.lock().expect("lock must succeed").as_slice(),
+            [("untouched".to_string(), "value".to_string())],
+            "dry run must not reach the provider"
+        );
+    }
+
+    #[tokio::test]
+    async fn dry_run_reports_failed_stage_and_skips_the_rest() {
+        let app = build_router(test_app_state(false));
+        let response = app
+            .oneshot(dry_run_request(
+                "/api/v1/responses",
+                r#"{"model":"deepseek/deepseek-chat","input":"   ","stream":false}"#,
+            ))
+            .await
+            .expect("request must complete");
+
+        assert_eq!(response.status(), StatusCode::OK);
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let payload: Value =
+            serde_json::from_slice(&body).expect("response body must be valid json");
+        assert_eq!(payload.get("accepted"), Some(&Value::Bool(false)));
+        let stages = payload.get("stages").and_then(Value::as_array).expect("stages must exist");
+        let statuses = stages
+            .iter()
+            .map(|stage| {
+                (
+                    stage.get("stage").and_then(Value::as_str).unwrap_or_default(),
+                    stage.get("status").and_then(Value::as_str).unwrap_or_default(),
+                )
+            })
+            .collect::<Vec<_>>();
+        assert_eq!(
+            statuses,
+            [
+                ("auth", "ok"),
+                ("model_resolution", "ok"),
+                ("ingest", "failed"),
+                ("tokenize", "skipped")
+            ]
+        );
+        assert_eq!(
+            stages[2].pointer("/error/code").and_then(Value::as_str),
+            Some("invalid_request")
+        );
+    }
+
+    #[tokio::test]
+    async fn dry_run_is_rate_limited_separately() {
+        let app = build_router(test_app_state(false).with_dry_run_limits(1, 10));
+        let body = r#"{"model":"deepseek/deepseek-chat","input":"hello","stream":false}"#;
+
+        let first = app
+            .clone()
+            .oneshot(dry_run_request(
+                "/api/v1/chat/completions",
+                r#"{"model":"deepseek/deepseek-chat","messages":[{"role":"user","content":"hello"}]}"#,
+            ))
+            .await
+            .expect("request must complete");
+        assert_eq!(first.status(), StatusCode::OK);
+
+        let second = app
+            .clone()
+            .oneshot(dry_run_request("/api/v1/responses", body))
+            .await
+            .expect("request must complete");
+        let (status, envelope) = error_envelope(second).await;
+        assert_eq!(status, StatusCode::TOO_MANY_REQUESTS);
+        assert_eq!(envelope.get("code").and_then(Value::as_str), Some("dry_run_rate_limited"));
+
+        let regular = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/responses")
+                    .header("content-type", "application/json")
+                    .body(Body::from(body))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        assert_eq!(regular.status(), StatusCode::OK);
+    }
+
+    #[tokio::test]
+    async fn dry_run_budget_is_tracked_per_caller() {
+        let app = build_router(test_app_state(false).with_dry_run_limits(1, 10));
+        let body = r#"{"model":"deepseek/deepseek-chat","input":"hello","stream":false}"#;
+        let dry_run_as = |token: &str| {
+            let mut request = dry_run_request("/api/v1/responses", body);
+            request.headers_mut().insert(
+                "authorization",
+                format!("Bearer {token}").parse().expect("header value must parse"),
+            );
+            request
+        };
+
+        let first =
+            app.clone().oneshot(dry_run_as("caller-a")).await.expect("request must complete");
+        assert_eq!(first.status(), StatusCode::OK);
+
+        let second =
+            app.clone().oneshot(dry_run_as("caller-a")).await.expect("request must complete");
+        let (status, envelope) = error_envelope(second).await;
+        assert_eq!(status, StatusCode::TOO_MANY_REQUESTS);
+        assert_eq!(envelope.get("code").and_then(Value::as_str), Some("dry_run_rate_limited"));
+
+        let other = app.oneshot(dry_run_as("caller-b")).await.expect("request must complete");
+        assert_eq!(other.status(), StatusCode::OK);
+    }
+
+    async fn chat_completion_content(app: axum::Router, body: String) -> (StatusCode, Value) {
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/chat/completions")
+                    .header("content-type", "application/json")
+                    .body(Body::from(body))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        let status = response.status();
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        (status, serde_json::from_slice(&body).expect("response body must be valid json"))
+    }
+
+    #[tokio::test]
+    async fn chat_assistant_prefill_follows_provider_capability_and_policy() {
+        let prefill_body = |model: &str| {
+            json!({
+                "model": model,
+                "messages": [
+                    {"role": "developer", "content": "be brief"},
+                    {"role": "user", "content": "count"},
+                    {"role": "assistant", "content": "one two"}
+                ]
+            })
+            .to_string()
+        };
+
+        let (status, payload) = chat_completion_content(
+            build_router(test_app_state(false)),
+            prefill_body("openrouter/openai/gpt-5.2"),
+        )
+        .await;
+        assert_eq!(status, StatusCode::OK);
+        let content = payload.pointer("/choices/0/message/content").and_then(Value::as_str);
+        assert_eq!(
+            content.map(str::trim),
+            Some("[openrouter] developer:be brief user:count assistant:one two")
+        );
+
+        let (status, payload) = chat_completion_content(
+            build_router(test_app_state(false)),
+            prefill_body("deepseek/deepseek-chat"),
+        )
+        .await;
+        assert_eq!(status, StatusCode::OK);
+        let content = payload
+            .pointer("/choices/0/message/content")
+            .and_then(Value::as_str)
+            .expect("content must be present");
+        assert!(content.contains("user:Continue the assistant reply"));
+        assert!(!content.contains("assistant:one two"));
+
+        let state = test_app_state(false)
+            .with_assistant_prefill(crate::config::AssistantPrefillPolicy::Reject);
+        let (status, payload) =
+            chat_completion_content(build_router(state), prefill_body("deepseek/deepseek-chat"))
+                .await;
+        assert_eq!(status, StatusCode::BAD_REQUEST);
+        assert_eq!(
+            payload.pointer("/error/code").and_then(Value::as_str),
+            Some("assistant_prefill_not_supported")
+        );
+
+        let tool_without_id = json!({
+            "model": "openrouter/openai/gpt-5.2",
+            "messages": [
+                {"role": "tool", "content": "42"},
+                {"role": "assistant", "content": "one two"}
+            ]
+        })
+        .to_string();
+        let (status, payload) =
+            chat_completion_content(build_router(test_app_state(false)), tool_without_id).await;
+        assert_eq!(status, StatusCode::BAD_REQUEST);
+        assert_eq!(
+            payload.pointer("/error/message").and_then(Value::as_str),
+            Some("validation failed: tool message must have a tool_call_id")
+        );
+    }
+
+    #[tokio::test]
+    async fn chat_stream_does_not_reemit_emulated_assistant_prefill() {
+        let seen_input = Arc::new(Mutex::new(None));
+        let app = build_openrouter_app(Arc::new(PrefillContinuationProvider {
+            seen_input: seen_input.clone(),
+        }));
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/chat/completions")
+                    .header("content-type", "application/json")
+                    .body(Body::from(
+                        json!({
+                            "model": "openai/gpt-5-mini",
+                            "messages": [
+                                {"role": "user", "content": "count"},
+                                {"role": "assistant", "content": "one two"}
+                            ],
+                            "stream": true
+                        })
+                        .to_string(),
+                    ))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+
+        assert_eq!(response.status(), StatusCode::OK);
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let streamed = String::from_utf8_lossy(&body)
+            .lines()
+            .filter_map(|line| line.strip_prefix("data: "))
+            .filter(|data| *data != "[DONE]")
+            .filter_map(|data| serde_json::from_str::<Value>(data).ok())
+            .filter_map(|chunk| {
+                chunk
+                    .pointer("/choices/0/delta/content")
+                    .and_then(Value::as_str)
+                    .map(str::to_string)
+            })
+            .collect::<String>();
+        assert_eq!(streamed, " three four");
+
+        // The client has no native prefill, so the prefill only reaches the provider as part of
+        // the continuation prompt and never comes back through the stream.
+        let seen_input =
+            seen_input.lock().expect("lock must succeed").clone().expect("provider must be called");
+        assert!(seen_input.contains("Continue the assistant reply"));
+        assert!(!seen_input.contains("assistant:one two"));
+    }
+
+    #[tokio::test]
+    async fn deprecated_model_is_listed_and_rejected_unless_allowed() {
+        let mut config = crate::config::AppConfig::for_tests();
+        config.model_deprecations.insert(
+            "deepseek/deepseek-chat".to_string(),
+            crate::config::ModelDeprecation {
+                deprecated_at: "2000-01-01".to_string(),
+                replacement: Some("deepseek/deepseek-reasoner".to_string()),
+            },
+        );
+        let app = AppBuilder::new(&config).build_router();
+
+        let response = app
+            .clone()
+            .oneshot(
+                Request::builder()
+                    .method("GET")
+                    .uri("/api/v1/models")
+                    .body(Body::empty())
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let models: Value = serde_json::from_slice(&body).expect("models must be valid json");
+        let entry = models["data"]
+            .as_array()
+            .and_then(|data| data.iter().find(|m| m["id"] == "deepseek/deepseek-chat"))
+            .expect("deprecated model must stay listed");
+        assert_eq!(entry["deprecated_at"], "2000-01-01");
+        assert_eq!(entry["replacement"], "deepseek/deepseek-reasoner");
+
+        let body = json!({
+            "model": "deepseek/deepseek-chat",
+            "messages": [{"role": "user", "content": "hello"}]
+        })
+        .to_string();
+        let (status, payload) = chat_completion_content(app.clone(), body.clone()).await;
+        assert_eq!(status, StatusCode::GONE);
+        assert_eq!(
+            payload.pointer("/error/code").and_then(Value::as_str),
+            Some("model_deprecated")
+        );
+        assert_eq!(payload.pointer("/error/param").and_then(Value::as_str), Some("model"));
+        assert!(
+            payload
+                .pointer("/error/message")
+                .and_then(Value::as_str)
+                .is_some_and(|message| message.contains("use deepseek/deepseek-reasoner instead"))
+        );
+
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/chat/completions")
+                    .header("content-type", "application/json")
+                    .header("x-allow-deprecated-models", "true")
+                    .body(Body::from(body))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        assert_eq!(response.status(), StatusCode::OK);
+    }
+
+    #[tokio::test]
+    async fn open_circuit_short_circuits_requests_for_that_model_only() {
+        let mut config = crate::config::AppConfig::for_tests();
+        config.circuit_breaker_threshold = 1;
+        let state = AppBuilder::new(&config).build_state();
+        state.circuit_breakers.record(
+            "deepseek/deepseek-chat",
+            Some(&CoreError::Provider("connection reset".to_string())),
+        );
+        let app = build_router(state);
+
+        let body = json!({
+            "model": "deepseek/deepseek-chat",
+            "messages": [{"role": "user", "content": "hello"}]
+        })
+        .to_string();
+        let (status, payload) = chat_completion_content(app.clone(), body).await;
+        assert_eq!(status, StatusCode::SERVICE_UNAVAILABLE);
+        assert_eq!(
+            payload.pointer("/error/code").and_then(Value::as_str),
+            Some("provider_circuit_open")
+        );
+
+        let body = json!({
+            "model": "deepseek/deepseek-reasoner",
+            "messages": [{"role": "user", "content": "hello"}]
+        })
+        .to_string();
+        let (status, _) = chat_completion_content(app, body).await;
+        assert_eq!(status, StatusCode::OK);
+    }
+
+    #[tokio::test]
+    async fn disabled_endpoint_returns_503_with_outage_message() {
+        let mut config = crate::config::AppConfig::for_tests();
+        config
+            .disabled_endpoints
+            .insert(crate::config::EndpointClass::Chat, "chat is paused".to_string());
+        let app = AppBuilder::new(&config).build_router();
+
+        let body = json!({
+            "model": "deepseek/deepseek-chat",
+            "messages": [{"role": "user", "content": "hello"}]
+        })
+        .to_string();
+        let (status, payload) = chat_completion_content(app.clone(), body).await;
+        assert_eq!(status, StatusCode::SERVICE_UNAVAILABLE);
+        assert_eq!(
+            payload.pointer("/error/code").and_then(Value::as_str),
+            Some("endpoint_disabled")
+        );
+        assert_eq!(
+            payload.pointer("/error/message").and_then(Value::as_str),
+            Some("chat is paused")
+        );
+
+        let response = app
+            .clone()
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/responses")
+                    .header("content-type", "application/json")
+                    .body(Body::from(
+                        json!({"model": "deepseek/deepseek-chat", "input": "hello"}).to_string(),
+                    ))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        assert_eq!(response.status(), StatusCode::OK);
+
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("GET")
+                    .uri("/health")
+                    .body(Body::empty())
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        let body = to_bytes(response.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        let health: Value = serde_json::from_slice(&body).expect("health must be valid json");
+        assert_eq!(health, json!({"status": "healthy", "disabled_endpoints": ["chat"]}));
+    }
+
+    #[tokio::test]
+    async fn oversized_request_body_returns_413_in_error_envelope() {
+        let mut config = crate::config::AppConfig::for_tests();
+        config.max_request_body_bytes = 64;
+        let app = AppBuilder::new(&config).build_router();
+        let body = json!({
+            "model": "deepseek/deepseek-chat",
+            "messages": [{"role": "user", "content": "x".repeat(128)}]
+        })
+        .to_string();
+
+        let (status, payload) = chat_completion_content(app.clone(), body.clone()).await;
+        assert_eq!(status, StatusCode::PAYLOAD_TOO_LARGE);
+        assert_eq!(
+            payload.pointer("/error/code").and_then(Value::as_str),
+            Some("request_too_large")
+        );
+
+        let response = app
+            .oneshot(
+                Request::builder()
+                    .method("POST")
+                    .uri("/api/v1/chat/completions")
+                    .header("content-type", "application/json")
+                    .header("idempotency-key", "oversized-1")
+                    .body(Body::from(body))
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+        assert_eq!(response.status(), StatusCode::PAYLOAD_TOO_LARGE);
+    }
+
+    fn cors_test_app() -> axum::Router {
+        let mut config = crate::config::AppConfig::for_tests();
+        config.byok_enabled = true;
+        config.cors.allowed_origins = vec!["https://*.example.com".to_string()];
+        AppBuilder::new(&config).build_router()
+    }
+
+    #[tokio::test]
+    async fn cors_preflight_short_circuits_before_auth() {
+        let response = cors_test_app()
+            .oneshot(
+                Request::builder()
+                    .method("OPTIONS")
+                    .uri("/api/v1/chat/completions")
+                    .header("origin", "https://play.example.com")
+                    .header("access-control-request-method", "POST")
+                    .header("access-control-request-headers", "authorization, content-type")
+                    .body(Body::empty())
+                    .expect("request must build"),
+            )
+            .await
+            .expect("request must complete");
+
+        assert_eq!(response.status(), StatusCode::NO_CONTENT);
+        let headers = response.headers();
+        assert_eq!(
+            headers.get("access-control-allow-origin").and_then(|v| v.to_str().ok()),
+            Some("https://play.example.com")
+        );
+        assert_eq!(
+            headers.get("access-control-allow-methods").and_then(|v| v.to_str().ok()),
+            Some("GET, POST, OPTIONS")
+        );
+        assert!(
+            headers
+                .get("access-control-allow-headers")
+                .and_then(|v| v.to_str().ok())
+                .is_some_and(|v| v.contains("authorization"))
+        );
+        assert_eq!(
+            headers.get("access-control-max-age").and_then(|v| v.to_str().ok()),
+            Some("600")
+        );
+    }
+
+    #[tokio::test]
+    async fn cors_headers_are_added_only_for_allowed_origins() {
+        let request = |origin: &str| {
+            Request::builder()
+                .method("POST")
+                .uri("/api/v1/responses")
+                .header("content-type", "application/json")
+                .header("origin", origin)
+                .body(Body::from(r#"{"model":"deepseek/deepseek-chat","input":"hello"}"#))
+                .expect("request must build")
+        };
+
+        let allowed = cors_test_app()
+            .oneshot(request("https://play.example.com"))
+            .await
+            .expect("request must complete");
+        assert_eq!(allowed.status(), StatusCode::UNAUTHORIZED);
+        assert_eq!(
+            allowed.headers().get("access-control-allow-origin").and_then(|v| v.to_str().ok()),
+            Some("https://play.example.com")
+        );
+
+        let rejected = cors_test_app()
+            .oneshot(request("https://example.org"))
+            .await
+            .expect("request must complete");
+        assert!(!rejected.headers().contains_key("access-control-allow-origin"));
+
+        let default_app = build_router(test_app_state(false));
+        let same_origin_only = default_app
+            .oneshot(request("https://play.example.com"))
+            .await
+            .expect("request must complete");
+        assert_eq!(same_origin_only.status(), StatusCode::OK);
+        assert!(!same_origin_only.headers().contains_key("access-control-allow-origin"));
+    }
+
+    fn idempotent_request(key: &str, body: &'static str) -> Request<Body> {
+        Request::builder()
+            .method("POST")
+            .uri("/api/v1/responses")
+            .header("content-type", "application/json")
+            .header("idempotency-key", key)
+            .body(Body::from(body))
+            .expect("request must build")
+    }
+
+    #[tokio::test]
+    async fn idempotency_key_replays_response_and_rejects_different_body() {
+        let app = build_router(test_app_state(false));
+        let body = r#"{"model":"deepseek/deepseek-chat","input":"hello","stream":false}"#;
+
+        let first = app
+            .clone()
+            .oneshot(idempotent_request("retry-1", body))
+            .await
+            .expect("request must complete");
+        assert_eq!(first.status(), StatusCode::OK);
+        assert!(!first.headers().contains_key("idempotent-replayed"));
+        let first_body =
+            to_bytes(first.into_body(), usize::MAX).await.expect("response body read must succeed");
+
+        let replay = app
+            .clone()
+            .oneshot(idempotent_request("retry-1", body))
+            .await
+            .expect("request must complete");
+        assert_eq!(replay.status(), StatusCode::OK);
+        assert_eq!(
+            replay.headers().get("idempotent-replayed").and_then(|v| v.to_str().ok()),
+            Some("true")
+        );
+        let replay_body = to_bytes(replay.into_body(), usize::MAX)
+            .await
+            .expect("response body read must succeed");
+        assert_eq!(replay_body, first_body, "replay must return the stored response");
+
+        let conflict = app
+            .oneshot(idempotent_request(
+                "retry-1",
+                r#"{"model":"deepseek/deepseek-chat","input":"bye","stream":false}"#,
+            ))
+            .await
+            .expect("request must complete");
+        let (status, envelope) = error_envelope(conflict).await;
+        assert_eq!(status, StatusCode::CONFLICT);
+        assert_eq!(envelope.get("code").and_then(Value::as_str), Some("idempotency_key_reused"));
+    }
+
+    #[tokio::test]
+    async fn concurrent_first_attempts_with_same_idempotency_key_execute_once() {
+        let calls = Arc::new(AtomicUsize::new(0));
+        let app =
+            build_openrouter_app(Arc::new(CountingProvider { calls: calls.clone(), fail: false }));
+        let body = r#"{"model":"openai/gpt-5-mini","input":"hello","stream":false}"#;
+
+        let (first, second) = tokio::join!(
+            app.clone().oneshot(idempotent_request("concurrent-1", body)),
+            app.clone().oneshot(idempotent_request("concurrent-1", body)),
+        );
+        let responses =
+            [first.expect("request must complete"), second.expect("request must complete")];
+
+        assert_eq!(calls.load(Ordering::SeqCst), 1, "provider must run exactly once");
+        assert!(responses.iter().all(|response| response.status() == StatusCode::OK));
+        let replayed = responses
+            .iter()
+            .filter(|response| {
+                response.headers().get("idempotent-replayed").and_then(|v| v.to_str().ok())
+                    == Some("true")
+            })
+            .count();
+        assert_eq!(replayed, 1, "exactly one response must be a replay");
+    }
+
+    #[tokio::test]
+    async fn concurrent_attempts_after_a_failure_run_again_without_replay() {
+        let calls = Arc::new(AtomicUsize::new(0));
+        let app =
+            build_openrouter_app(Arc::new(CountingProvider { calls: calls.clone(), fail: true }));
+        let body = r#"{"model":"openai/gpt-5-mini","input":"hello","stream":false}"#;
+
+        let (first, second) = tokio::join!(
+            app.clone().oneshot(idempotent_request("concurrent-2", body)),
+            app.clone().oneshot(idempotent_request("concurrent-2", body)),
+        );
+        let responses =
+            [first.expect("request must complete"), second.expect("request must complete")];
+
+        assert_eq!(calls.load(Ordering::SeqCst), 2, "a failed attempt must not be shared");
+        assert!(responses.iter().all(|response| response.status() == StatusCode::BAD_GATEWAY));
+        assert!(
+            responses
+                .iter()
+                .all(|response| !response.headers().contains_key("idempotent-replayed"))
+        );
+    }
+
+    #[tokio::test]
+    async fn idempotency_key_does_not_cache_failures_or_streams() {
+        let app = build_router(test_app_state(false));
+        let failing =
+            r#"{"model":"deepseek/deepseek-chat","input":"__FAIL_PROVIDER__","stream":false}"#;
+        for _ in 0..2 {
+            let response = app
+                .clone()
+                .oneshot(idempotent_request("retry-2", failing))
+                .await
+                .expect("request must complete");
+            assert_eq!(response.status(), StatusCode::BAD_GATEWAY);
+            assert!(!response.headers().contains_key("idempotent-replayed"));
+        }
+
+        let streaming = r#"{"model":"deepseek/deepseek-chat","input":"hello","stream":true}"#;
+        for _ in 0..2 {
+            let response = app
+                .clone()
+                .oneshot(idempotent_request("retry-3", streaming))
+                .await
+                .expect("request must complete");
+            assert_eq!(response.status(), StatusCode::OK);
+            assert!(!response.headers().contains_key("idempotent-replayed"));
+        }
     }
 }
@@ -1,15 +1,21 @@
 use std::net::SocketAddr;
 
-use tracing::info;
+use tracing::{error, info, warn};
 use xrouter_app::{AppBuilder, config::AppConfig};
 use xrouter_observability::init_observability;
 
+const IGNORE_CONFIG_ERRORS_FLAG: &str = "--ignore-config-errors";
+
 #[tokio::main]
 async fn main() {
     let _ = dotenvy::dotenv();
     init_observability("xrouter-app");
 
     let config = AppConfig::from_env().expect("configuration must be valid");
+    let ignore_config_errors = std::env::args().skip(1).any(|arg| arg == IGNORE_CONFIG_ERRORS_FLAG);
+    let Some(addr) = check_config(&config, ignore_config_errors) else {
+        std::process::exit(1);
+    };
     info!(
         event = "app.starting",
         host = %config.host,
@@ -18,9 +24,72 @@ async fn main() {
         provider_max_inflight = config.provider_max_inflight
     );
     let app = AppBuilder::new(&config).build_router();
-    let addr: SocketAddr =
-        format!("{}:{}", config.host, config.port).parse().expect("socket address must be valid");
 
     let listener = tokio::net::TcpListener::bind(addr).await.expect("listener must bind");
     axum::serve(listener, app).await.expect("server must run");
 }
+
+/// Logs every configuration issue and returns the bind address when startup may continue.
+/// `--ignore-config-errors` skips fatal issues, but never an address the server cannot bind to.
+fn check_config(config: &AppConfig, ignore_config_errors: bool) -> Option<SocketAddr> {
+    let issues = config.validate();
+    for issue in &issues {
+        if issue.is_fatal() {
+            error!(event = "app.config.invalid", setting = %issue.setting, reason = %issue.message);
+        } else {
+            warn!(event = "app.config.warning", setting = %issue.setting, reason = %issue.message);
+        }
+    }
+    let fatal_count = issues.iter().filter(|issue| issue.is_fatal()).count();
+    let Some(addr) = config.bind_addr() else {
+        error!(
+            event = "app.config.rejected",
+            fatal_count = fatal_count,
+            hint = "XR_HOST must be an IP address; --ignore-config-errors does not apply to it"
+        );
+        return None;
+    };
+    if fatal_count == 0 {
+        return Some(addr);
+    }
+    if ignore_config_errors {
+        warn!(event = "app.config.errors_ignored", fatal_count = fatal_count);
+        return Some(addr);
+    }
+    error!(
+        event = "app.config.rejected",
+        fatal_count = fatal_count,
+        hint = "fix the settings above or pass --ignore-config-errors"
+    );
+    None
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn config_without_providers() -> AppConfig {
+        let mut config = AppConfig::for_tests();
+        for provider in config.providers.values_mut() {
+            provider.enabled = false;
+        }
+        config
+    }
+
+    #[test]
+    fn ignore_config_errors_continues_past_fatal_issues() {
+        let config = config_without_providers();
+
+        assert_eq!(check_config(&config, false), None);
+        assert_eq!(check_config(&config, true), "127.0.0.1:3000".parse().ok());
+    }
+
+    #[test]
+    fn unbindable_host_stops_startup_even_when_errors_are_ignored() {
+        let mut config = AppConfig::for_tests();
+        config.host = "localhost".to_string();
+
+        assert_eq!(check_config(&config, false), None);
+        assert_eq!(check_config(&config, true), None);
+    }
+}
@@ -38,6 +38,23 @@ impl<'a> AppBuilder<'a> {
             models,
             engines,
         )
+        .with_max_request_body_bytes(self.config.max_request_body_bytes)
+        .with_dry_run_limits(
+            self.config.dry_run_max_per_minute,
+            self.config.dry_run_global_max_per_minute,
+        )
+        .with_idempotency(
+            self.config.idempotency_ttl_seconds,
+            self.config.max_upstream_response_bytes,
+        )
+        .with_circuit_breaker(
+            self.config.circuit_breaker_threshold,
+            self.config.circuit_breaker_open_seconds,
+        )
+        .with_assistant_prefill(self.config.assistant_prefill)
+        .with_cors(&self.config.cors)
+        .with_model_deprecations(&self.config.model_deprecations)
+        .with_disabled_endpoints(&self.config.disabled_endpoints)
     }
 
     pub fn build_router(&self) -> Router {
@@ -1,14 +1,15 @@
 use std::{collections::HashMap, sync::Arc};
 
-use tracing::{debug, info};
+use axum::http::{HeaderName, HeaderValue};
+use tracing::{debug, info, warn};
 use xrouter_clients_openai::{
     DeepSeekClient, GigachatClient, MockProviderClient, OpenAiClient, OpenRouterClient,
     XrouterClient, YandexResponsesClient, ZaiClient, build_http_client,
-    build_http_client_insecure_tls,
+    build_http_client_insecure_tls, build_http_client_with_headers,
 };
 use xrouter_core::{ExecutionEngine, ProviderClient};
 
-use crate::config;
+use crate::config::{self, PROVIDER_KEY_PLACEHOLDER};
 
 pub(crate) fn build_engines(config: &config::AppConfig) -> HashMap<String, Arc<ExecutionEngine>> {
     let mut engines = HashMap::new();
@@ -21,57 +22,82 @@ pub(crate) fn build_engines(config: &config::AppConfig) -> HashMap<String, Arc<E
         }
 
         let client: Arc<dyn ProviderClient> = if cfg!(test) {
-            Arc::new(MockProviderClient::new(provider.to_string()))
+            // Reports the prefill capability of the client built below for the same key; only
+            // OpenRouterClient has native support.
+            Arc::new(
+                MockProviderClient::new(provider.to_string())
+                    .with_assistant_prefill(provider == "openrouter"),
+            )
         } else {
+            let insecure_tls = provider == "gigachat" && config.gigachat_insecure_tls;
+            let http_client = if !provider_config.headers.is_empty() {
+                build_http_client_with_headers(
+                    config.provider_timeout_seconds,
+                    insecure_tls,
+                    &resolve_provider_headers(provider, provider_config),
+                )
+            } else if insecure_tls {
+                build_http_client_insecure_tls(config.provider_timeout_seconds)
+            } else {
+                shared_http_client.clone()
+            };
+            let api_key = if provider_config.suppress_bearer {
+                None
+            } else {
+                provider_config.api_key.clone()
+            };
             match provider.as_str() {
                 "openrouter" => Arc::new(OpenRouterClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
-                    shared_http_client.clone(),
+                    api_key,
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 "deepseek" => Arc::new(DeepSeekClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
-                    shared_http_client.clone(),
+                    api_key,
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 "zai" => Arc::new(ZaiClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
-                    shared_http_client.clone(),
+                    api_key,
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 "yandex" => Arc::new(YandexResponsesClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
+                    api_key,
                     provider_config.project.clone(),
-                    shared_http_client.clone(),
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 "gigachat" => Arc::new(GigachatClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
+                    api_key,
                     None,
-                    if config.gigachat_insecure_tls {
-                        build_http_client_insecure_tls(config.provider_timeout_seconds)
-                    } else {
-                        shared_http_client.clone()
-                    },
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 "xrouter" => Arc::new(XrouterClient::new(
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
-                    shared_http_client.clone(),
+                    api_key,
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
                 _ => Arc::new(OpenAiClient::new(
                     provider.to_string(),
                     provider_config.base_url.clone(),
-                    provider_config.api_key.clone(),
-                    shared_http_client.clone(),
+                    api_key,
+                    http_client,
                     Some(config.provider_max_inflight),
+                    Some(config.max_upstream_response_bytes),
                 )),
             }
         };
@@ -86,3 +112,106 @@ pub(crate) fn build_engines(config: &config::AppConfig) -> HashMap<String, Arc<E
     );
     engines
 }
+
+fn resolve_provider_headers(
+    provider: &str,
+    provider_config: &config::ProviderConfig,
+) -> Vec<(String, String)> {
+    let mut resolved = Vec::with_capacity(provider_config.headers.len());
+    for (name, value) in &provider_config.headers {
+        let value = if !value.contains(PROVIDER_KEY_PLACEHOLDER) {
+            value.clone()
+        } else if let Some(api_key) = provider_config.api_key.as_deref() {
+            value.replace(PROVIDER_KEY_PLACEHOLDER, api_key)
+        } else {
+            warn!(
+                event = "app.provider.custom_header.skipped",
+                provider = provider,
+                header = %name,
+                reason = "api_key_not_configured"
+            );
+            continue;
+        };
+        // One invalid header would fail the whole HTTP client, so drop it instead. The value is
+        // never logged because it may contain the API key.
+        if HeaderName::from_bytes(name.as_bytes()).is_err()
+            || HeaderValue::from_str(&value).is_err()
+        {
+            warn!(
+                event = "app.provider.custom_header.skipped",
+                provider = provider,
+                header = %name,
+                reason = "invalid_header"
+            );
+            continue;
+        }
+        resolved.push((name.clone(), value));
+    }
+    debug!(
+        event = "app.provider.custom_headers",
+        provider = provider,
+        headers = ?resolved.iter().map(|(name, _)| name.as_str()).collect::<Vec<_>>(),
+        suppress_bearer = provider_config.suppress_bearer
+    );
+    resolved
+}
+
+#[cfg(test)]
+mod tests {
+    use super::resolve_provider_headers;
+    use crate::config::ProviderConfig;
+
+    fn provider_config(api_key: Option<&str>, headers: &[(&str, &str)]) -> ProviderConfig {
+        ProviderConfig {
+            enabled: true,
+            api_key: api_key.map(str::to_string),
+            base_url: None,
+            project: None,
+            headers: headers
+                .iter()
+                .map(|(name, value)| (name.to_string(), value.to_string()))
+                .collect(),
+            suppress_bearer: true,
+        }
+    }
+
+    #[test]
+    fn substitutes_api_key_into_templated_headers() {
+        let config = provider_config(
+            Some("secret-key"),
+            &[("api-key", "{{key}}"), ("x-auth", "Token {{key}}"), ("x-api-version", "2024-10-21")],
+        );
+
+        assert_eq!(
+            resolve_provider_headers("openai", &config),
+            [
+                ("api-key".to_string(), "secret-key".to_string()),
+                ("x-auth".to_string(), "Token secret-key".to_string()),
+                ("x-api-version".to_string(), "2024-10-21".to_string()),
+            ]
+        );
+    }
+
+    #[test]
+    fn skips_templated_headers_without_api_key() {
+        let config = provider_config(None, &[("api-key", "{{key}}"), ("x-api-version", "v1")]);
+
+        assert_eq!(
+            resolve_provider_headers("openai", &config),
+            [("x-api-version".to_string(), "v1".to_string())]
+        );
+    }
+
+    #[test]
+    fn skips_invalid_header_names_and_values() {
+        let config = provider_config(
+            Some("bad\nkey"),
+            &[("api-key", "{{key}}"), ("bad header", "value"), ("x-api-version", "v1")],
+        );
+
+        assert_eq!(
+            resolve_provider_headers("openai", &config),
+            [("x-api-version".to_string(), "v1".to_string())]
+        );
+    }
+}
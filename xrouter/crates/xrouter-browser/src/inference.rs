@@ -480,6 +480,7 @@ mod tests {
         let outcome = xrouter_core::ProviderOutcome {
             chunks: Vec::new(),
             output_tokens: 7,
+            reasoning_tokens: 0,
             reasoning: None,
             reasoning_details: None,
             tool_calls: Some(vec![xrouter_contracts::ToolCall {
@@ -512,6 +513,7 @@ mod tests {
         let outcome = xrouter_core::ProviderOutcome {
             chunks: vec!["hello ".to_string(), "world".to_string()],
             output_tokens: 2,
+            reasoning_tokens: 0,
             reasoning: Some("thinking".to_string()),
             reasoning_details: None,
             tool_calls: None,
@@ -117,6 +117,7 @@ impl BrowserProviderRuntime {
         Ok(ProviderOutcome {
             chunks: all_chunks.to_vec(),
             output_tokens,
+            reasoning_tokens: 0,
             reasoning: None,
             reasoning_details: None,
             tool_calls: None,
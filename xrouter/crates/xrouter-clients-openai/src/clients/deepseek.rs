@@ -29,6 +29,7 @@ impl DeepSeekClient {
         api_key: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         Self::with_runtime(Arc::new(HttpRuntime::new(
             "deepseek".to_string(),
@@ -36,6 +37,7 @@ impl DeepSeekClient {
             api_key,
             http_client,
             max_inflight,
+            max_response_bytes,
         )))
     }
 
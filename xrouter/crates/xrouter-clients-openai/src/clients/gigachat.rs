@@ -39,6 +39,7 @@ impl GigachatClient {
         scope: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         Self {
             runtime: Arc::new(HttpRuntime::new(
@@ -47,6 +48,7 @@ impl GigachatClient {
                 authorization_key,
                 http_client,
                 max_inflight,
+                max_response_bytes,
             )),
             scope: scope.unwrap_or_else(|| GIGACHAT_DEFAULT_SCOPE.to_string()),
             token_state: Arc::new(Mutex::new(None)),
@@ -582,6 +584,7 @@ pub(crate) fn map_gigachat_chat_completion_response_value(
     Ok(ProviderOutcome {
         chunks: if content.is_empty() { Vec::new() } else { vec![content] },
         output_tokens,
+        reasoning_tokens: 0,
         reasoning: None,
         reasoning_details: None,
         tool_calls,
@@ -656,6 +659,7 @@ pub(crate) fn map_gigachat_chat_completion_stream_text(
     Ok(ProviderOutcome {
         chunks: if all_content.is_empty() { Vec::new() } else { chunks },
         output_tokens,
+        reasoning_tokens: 0,
         reasoning: None,
         reasoning_details: None,
         tool_calls,
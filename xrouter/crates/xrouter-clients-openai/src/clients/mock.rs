@@ -3,17 +3,27 @@ use xrouter_core::{CoreError, ProviderClient, ProviderGenerateRequest, ProviderO
 
 pub struct MockProviderClient {
     provider_id: String,
+    supports_assistant_prefill: bool,
 }
 
 impl MockProviderClient {
     pub fn new(provider_id: String) -> Self {
-        Self { provider_id }
+        Self { provider_id, supports_assistant_prefill: false }
+    }
+
+    pub fn with_assistant_prefill(mut self, supported: bool) -> Self {
+        self.supports_assistant_prefill = supported;
+        self
     }
 }
 
 #[cfg_attr(target_arch = "wasm32", async_trait(?Send))]
 #[cfg_attr(not(target_arch = "wasm32"), async_trait)]
 impl ProviderClient for MockProviderClient {
+    fn supports_assistant_prefill(&self) -> bool {
+        self.supports_assistant_prefill
+    }
+
     async fn generate(
         &self,
         request: ProviderGenerateRequest<'_>,
@@ -44,6 +54,7 @@ impl ProviderClient for MockProviderClient {
         Ok(ProviderOutcome {
             chunks,
             output_tokens,
+            reasoning_tokens: 0,
             reasoning,
             reasoning_details: None,
             tool_calls: None,
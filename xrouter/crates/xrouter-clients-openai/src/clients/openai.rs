@@ -27,6 +27,7 @@ impl OpenAiClient {
         api_key: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         Self::with_runtime(Arc::new(HttpRuntime::new(
             provider_id,
@@ -34,6 +35,7 @@ impl OpenAiClient {
             api_key,
             http_client,
             max_inflight,
+            max_response_bytes,
         )))
     }
 
@@ -29,6 +29,7 @@ impl OpenRouterClient {
         api_key: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         Self::with_runtime(Arc::new(HttpRuntime::new(
             "openrouter".to_string(),
@@ -36,6 +37,7 @@ impl OpenRouterClient {
             api_key,
             http_client,
             max_inflight,
+            max_response_bytes,
         )))
     }
 
@@ -47,6 +49,10 @@ impl OpenRouterClient {
 #[cfg_attr(target_arch = "wasm32", async_trait(?Send))]
 #[cfg_attr(not(target_arch = "wasm32"), async_trait)]
 impl ProviderClient for OpenRouterClient {
+    fn supports_assistant_prefill(&self) -> bool {
+        true
+    }
+
     async fn generate(
         &self,
         request: ProviderGenerateRequest<'_>,
@@ -441,6 +447,7 @@ mod tests {
             Ok(ProviderOutcome {
                 chunks: vec!["ok".to_string()],
                 output_tokens: 1,
+                reasoning_tokens: 0,
                 reasoning: None,
                 reasoning_details: None,
                 tool_calls: None,
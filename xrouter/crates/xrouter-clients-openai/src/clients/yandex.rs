@@ -35,6 +35,7 @@ impl YandexResponsesClient {
         project: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         Self::with_runtime(
             Arc::new(HttpRuntime::new(
@@ -43,6 +44,7 @@ impl YandexResponsesClient {
                 api_key,
                 http_client,
                 max_inflight,
+                max_response_bytes,
             )),
             project,
         )
@@ -576,6 +578,7 @@ pub(crate) fn map_yandex_responses_stream_text(
     Ok(ProviderOutcome {
         chunks: if all_content.is_empty() { Vec::new() } else { chunks },
         output_tokens,
+        reasoning_tokens: 0,
         reasoning: None,
         reasoning_details: None,
         tool_calls,
@@ -614,10 +617,18 @@ fn map_yandex_response_object(response: &Value) -> ProviderOutcome {
         .unwrap_or_else(|| {
             if content.is_empty() { 0 } else { content.split_whitespace().count() as u32 }
         });
+    let reasoning_tokens = response
+        .get("usage")
+        .and_then(|usage| usage.get("output_tokens_details"))
+        .and_then(|details| details.get("reasoning_tokens"))
+        .and_then(Value::as_u64)
+        .map(|v| v as u32)
+        .unwrap_or(0);
 
     ProviderOutcome {
         chunks: if content.is_empty() { Vec::new() } else { vec![content] },
         output_tokens,
+        reasoning_tokens,
         reasoning: None,
         reasoning_details: None,
         tool_calls: if tool_calls.is_empty() { None } else { Some(tool_calls) },
@@ -952,6 +963,18 @@ mod tests {
         assert_eq!(outcome.chunks.join(""), "hello");
     }
 
+    #[test]
+    fn yandex_completed_snapshot_reports_reasoning_tokens() {
+        let sse = concat!(
+            "data: {\"response\":{\"id\":\"resp_1\",\"output\":[],\"usage\":{\"output_tokens\":0},\"status\":\"in_progress\"}}\n\n",
+            "data: {\"response\":{\"id\":\"resp_1\",\"output\":[{\"type\":\"message\",\"content\":[{\"type\":\"output_text\",\"text\":\"hello\"}]}],\"usage\":{\"output_tokens\":6,\"output_tokens_details\":{\"reasoning_tokens\":5}},\"status\":\"completed\"}}\n\n"
+        );
+        let outcome =
+            map_yandex_responses_stream_text(sse).expect("snapshot responses SSE must parse");
+        assert_eq!(outcome.output_tokens, 6);
+        assert_eq!(outcome.reasoning_tokens, 5);
+    }
+
     #[test]
     fn yandex_extracts_text_when_output_uses_value_field() {
         let sse = "data: {\"response\":{\"id\":\"resp_1\",\"output\":[{\"type\":\"message\",\"content\":[{\"type\":\"output_text\",\"value\":\"ok\"}]}],\"status\":\"completed\"}}\n\n";
@@ -15,4 +15,6 @@ pub use clients::{
     DeepSeekClient, MockProviderClient, OpenAiClient, OpenRouterClient, XrouterClient, ZaiClient,
 };
 #[cfg(not(target_arch = "wasm32"))]
-pub use transport::{build_http_client, build_http_client_insecure_tls};
+pub use transport::{
+    build_http_client, build_http_client_insecure_tls, build_http_client_with_headers,
+};
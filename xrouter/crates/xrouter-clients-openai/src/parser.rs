@@ -27,6 +27,7 @@ pub fn map_chat_completion_response(
         return Err(CoreError::Provider("provider returned empty message content".to_string()));
     }
 
+    let reasoning_tokens = payload.usage.as_ref().map(Usage::reasoning_tokens).unwrap_or(0);
     let output_tokens =
         payload.usage.and_then(|usage| usage.completion_tokens).unwrap_or_else(|| {
             if content.is_empty() { 0 } else { content.split_whitespace().count() as u32 }
@@ -46,6 +47,7 @@ pub fn map_chat_completion_response(
     Ok(ProviderOutcome {
         chunks,
         output_tokens,
+        reasoning_tokens,
         reasoning,
         reasoning_details,
         tool_calls,
@@ -74,11 +76,18 @@ pub fn map_responses_api_response(
     let output_tokens = payload.usage.as_ref().map(|u| u.output_tokens).unwrap_or_else(|| {
         if content.is_empty() { 0 } else { content.split_whitespace().count() as u32 }
     });
+    let reasoning_tokens = payload
+        .usage
+        .as_ref()
+        .and_then(|u| u.output_tokens_details.as_ref())
+        .map(|details| details.reasoning_tokens)
+        .unwrap_or(0);
 
     let chunks = if content.is_empty() { Vec::new() } else { vec![content] };
     Ok(ProviderOutcome {
         chunks,
         output_tokens,
+        reasoning_tokens,
         reasoning,
         reasoning_details,
         tool_calls,
@@ -92,6 +101,7 @@ pub fn map_chat_completion_stream_text(payload: &str) -> Result<ProviderOutcome,
     let mut reasoning = String::new();
     let mut reasoning_details = Vec::<Value>::new();
     let mut output_tokens = None::<u32>;
+    let mut reasoning_tokens = 0u32;
     let mut tool_calls_by_index = HashMap::<usize, StreamToolCall>::new();
     let mut direct_tool_calls = Vec::<ToolCall>::new();
 
@@ -102,8 +112,11 @@ pub fn map_chat_completion_stream_text(payload: &str) -> Result<ProviderOutcome,
         let parsed: ChatCompletionsStreamChunk = serde_json::from_str(&event)
             .map_err(|err| CoreError::Provider(format!("provider stream parse failed: {err}")))?;
 
-        if let Some(usage) = parsed.usage.and_then(|usage| usage.completion_tokens) {
-            output_tokens = Some(usage);
+        if let Some(usage) = parsed.usage {
+            reasoning_tokens = usage.reasoning_tokens();
+            if let Some(tokens) = usage.completion_tokens {
+                output_tokens = Some(tokens);
+            }
         }
 
         for choice in parsed.choices {
@@ -191,6 +204,7 @@ pub fn map_chat_completion_stream_text(payload: &str) -> Result<ProviderOutcome,
     Ok(ProviderOutcome {
         chunks: final_chunks,
         output_tokens,
+        reasoning_tokens,
         reasoning,
         reasoning_details,
         tool_calls,
@@ -266,6 +280,7 @@ pub fn map_responses_stream_text(payload: &str) -> Result<ProviderOutcome, CoreE
     Ok(ProviderOutcome {
         chunks: if all_content.is_empty() { Vec::new() } else { chunks },
         output_tokens,
+        reasoning_tokens: 0,
         reasoning: None,
         reasoning_details: None,
         tool_calls,
@@ -396,6 +411,20 @@ pub(crate) struct Message {
 pub(crate) struct Usage {
     #[serde(default)]
     pub(crate) completion_tokens: Option<u32>,
+    #[serde(default)]
+    pub(crate) completion_tokens_details: Option<OutputTokensDetails>,
+}
+
+impl Usage {
+    fn reasoning_tokens(&self) -> u32 {
+        self.completion_tokens_details.as_ref().map(|details| details.reasoning_tokens).unwrap_or(0)
+    }
+}
+
+#[derive(Debug, Deserialize)]
+pub(crate) struct OutputTokensDetails {
+    #[serde(default)]
+    pub(crate) reasoning_tokens: u32,
 }
 
 #[derive(Debug, Deserialize)]
@@ -410,6 +439,8 @@ pub struct ResponsesApiResponse {
 pub(crate) struct ResponsesApiUsage {
     #[serde(default)]
     pub(crate) output_tokens: u32,
+    #[serde(default)]
+    pub(crate) output_tokens_details: Option<OutputTokensDetails>,
 }
 
 #[derive(Debug, Deserialize)]
@@ -518,7 +549,7 @@ mod tests {
                     }]),
                 },
             }],
-            usage: Some(Usage { completion_tokens: Some(7) }),
+            usage: Some(Usage { completion_tokens: Some(7), completion_tokens_details: None }),
         };
 
         let outcome = map_chat_completion_response(payload).expect("tool-only completion is valid");
@@ -552,7 +583,7 @@ mod tests {
                     tool_calls: None,
                 },
             }],
-            usage: Some(Usage { completion_tokens: Some(7) }),
+            usage: Some(Usage { completion_tokens: Some(7), completion_tokens_details: None }),
         };
 
         let outcome = map_chat_completion_response(payload).expect("dsml tool call must parse");
@@ -604,6 +635,53 @@ mod tests {
         assert!(outcome.tool_calls.is_none());
     }
 
+    #[test]
+    fn chat_sse_final_usage_chunk_reports_reasoning_tokens() {
+        let sse = concat!(
+            "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"index\":0,\"finish_reason\":null}]}\n\n",
+            "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":12,\"completion_tokens_details\":{\"reasoning_tokens\":9}}}\n\n",
+            "data: [DONE]\n\n"
+        );
+        let outcome = map_chat_completion_stream_text(sse).expect("usage chunk must parse");
+        assert_eq!(outcome.chunks.join(""), "ok");
+        assert_eq!(outcome.output_tokens, 12);
+        assert_eq!(outcome.reasoning_tokens, 9);
+    }
+
+    #[test]
+    fn chat_sse_usage_without_details_keeps_reasoning_tokens_zero() {
+        let sse = concat!(
+            "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"},\"index\":0}],\"usage\":{\"completion_tokens\":1}}\n\n",
+            "data: [DONE]\n\n"
+        );
+        let outcome = map_chat_completion_stream_text(sse).expect("usage chunk must parse");
+        assert_eq!(outcome.output_tokens, 1);
+        assert_eq!(outcome.reasoning_tokens, 0);
+    }
+
+    #[test]
+    fn map_chat_completion_response_reports_reasoning_tokens() {
+        let payload: ChatCompletionsResponse = serde_json::from_value(json!({
+            "choices": [{"message": {"content": "answer"}}],
+            "usage": {"completion_tokens": 40, "completion_tokens_details": {"reasoning_tokens": 32}}
+        }))
+        .expect("payload must deserialize");
+        let outcome = map_chat_completion_response(payload).expect("completion must map");
+        assert_eq!(outcome.output_tokens, 40);
+        assert_eq!(outcome.reasoning_tokens, 32);
+    }
+
+    #[test]
+    fn responses_sse_completed_usage_reports_reasoning_tokens() {
+        let sse = concat!(
+            "data: {\"type\":\"response.output_text.delta\",\"delta\":\"ok\"}\n\n",
+            "data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"output\":[{\"type\":\"message\",\"content\":[{\"type\":\"output_text\",\"text\":\"ok\"}]}],\"usage\":{\"output_tokens\":5,\"output_tokens_details\":{\"reasoning_tokens\":4}}}}\n\n"
+        );
+        let outcome = map_responses_stream_text(sse).expect("responses SSE must parse");
+        assert_eq!(outcome.output_tokens, 5);
+        assert_eq!(outcome.reasoning_tokens, 4);
+    }
+
     #[test]
     fn responses_sse_with_delta_only_is_not_empty() {
         let sse = concat!(
@@ -670,7 +748,7 @@ mod tests {
                 name: None,
                 arguments: None,
             }],
-            usage: Some(ResponsesApiUsage { output_tokens: 2 }),
+            usage: Some(ResponsesApiUsage { output_tokens: 2, output_tokens_details: None }),
         };
         let outcome = map_responses_api_response(payload).expect("message text must be extracted");
         assert_eq!(outcome.chunks.join(""), "helloworld");
@@ -690,7 +768,7 @@ mod tests {
                 name: None,
                 arguments: None,
             }],
-            usage: Some(ResponsesApiUsage { output_tokens: 2 }),
+            usage: Some(ResponsesApiUsage { output_tokens: 2, output_tokens_details: None }),
         };
 
         let outcome = map_responses_api_response(payload).expect("responses dsml must parse");
@@ -3,6 +3,40 @@ use xrouter_contracts::{
     ResponseInputContent, ResponseInputItem, ResponseToolOutput, ResponsesInput, ResponsesRequest,
 };
 
+const ASSISTANT_PREFILL_CONTINUATION_PROMPT: &str =
+    "Continue the assistant reply below from where it stops, without repeating it.";
+
+pub fn trailing_assistant_prefill(input: &ResponsesInput) -> Option<String> {
+    let ResponsesInput::Items(items) = input else {
+        return None;
+    };
+    let last = items.last()?;
+    if last.role.as_deref() != Some("assistant")
+        || !matches!(last.kind.as_deref(), None | Some("message"))
+    {
+        return None;
+    }
+    extract_input_item_text(last)
+}
+
+pub fn emulate_assistant_prefill(input: &ResponsesInput) -> Option<ResponsesInput> {
+    let prefill = trailing_assistant_prefill(input)?;
+    let ResponsesInput::Items(items) = input else {
+        return None;
+    };
+    let mut items = items.clone();
+    items.pop();
+    items.push(ResponseInputItem {
+        kind: Some("message".to_string()),
+        role: Some("user".to_string()),
+        content: Some(ResponseInputContent::Text(format!(
+            "{ASSISTANT_PREFILL_CONTINUATION_PROMPT}\n\n{prefill}"
+        ))),
+        ..Default::default()
+    });
+    Some(ResponsesInput::Items(items))
+}
+
 pub fn base_chat_payload(
     request: &ResponsesRequest,
     tools: Option<&[Value]>,
@@ -188,7 +222,10 @@ fn extract_summary_text(summary: &[Value]) -> Option<String> {
 
 #[cfg(test)]
 mod tests {
-    use super::build_chat_messages_from_responses_input;
+    use super::{
+        build_chat_messages_from_responses_input, emulate_assistant_prefill,
+        trailing_assistant_prefill,
+    };
     use xrouter_contracts::{
         ResponseInputContent, ResponseInputItem, ResponseToolOutput, ResponsesInput,
     };
@@ -270,4 +307,58 @@ mod tests {
         assert_eq!(messages[1]["role"], "user");
         assert_eq!(messages[1]["content"], "hello");
     }
+
+    fn message_item(role: &str, text: &str) -> ResponseInputItem {
+        ResponseInputItem {
+            kind: Some("message".to_string()),
+            role: Some(role.to_string()),
+            content: Some(ResponseInputContent::Text(text.to_string())),
+            ..Default::default()
+        }
+    }
+
+    #[test]
+    fn developer_role_maps_to_system_and_trailing_assistant_is_kept() {
+        let input = ResponsesInput::Items(vec![
+            message_item("developer", "answer in json"),
+            message_item("user", "list colors"),
+            message_item("assistant", "{\"colors\": ["),
+        ]);
+
+        let messages = build_chat_messages_from_responses_input(None, &input);
+        assert_eq!(messages.len(), 3);
+        assert_eq!(messages[0]["role"], "system");
+        assert_eq!(messages[2]["role"], "assistant");
+        assert_eq!(messages[2]["content"], "{\"colors\": [");
+        assert_eq!(trailing_assistant_prefill(&input).as_deref(), Some("{\"colors\": ["));
+    }
+
+    #[test]
+    fn emulated_prefill_replaces_trailing_assistant_with_continuation_prompt() {
+        let input = ResponsesInput::Items(vec![
+            message_item("user", "list colors"),
+            message_item("assistant", "red, green,"),
+        ]);
+
+        let emulated = emulate_assistant_prefill(&input).expect("prefill must be emulated");
+        let messages = build_chat_messages_from_responses_input(None, &emulated);
+        assert_eq!(messages.len(), 2);
+        assert_eq!(messages[1]["role"], "user");
+        let content = messages[1]["content"].as_str().expect("string content");
+        assert!(content.starts_with("Continue the assistant reply"));
+        assert!(content.ends_with("red, green,"));
+    }
+
+    #[test]
+    fn prefill_helpers_ignore_inputs_without_trailing_assistant_message() {
+        let text = ResponsesInput::Text("hello".to_string());
+        let items = ResponsesInput::Items(vec![
+            message_item("assistant", "earlier turn"),
+            message_item("user", "next question"),
+        ]);
+
+        assert_eq!(trailing_assistant_prefill(&text), None);
+        assert_eq!(trailing_assistant_prefill(&items), None);
+        assert_eq!(emulate_assistant_prefill(&items), None);
+    }
 }
@@ -37,6 +37,26 @@ pub fn build_http_client_insecure_tls(timeout_seconds: u64) -> Option<Client> {
         .ok()
 }
 
+pub fn build_http_client_with_headers(
+    timeout_seconds: u64,
+    insecure_tls: bool,
+    headers: &[(String, String)],
+) -> Option<Client> {
+    let mut default_headers = HeaderMap::new();
+    for (name, value) in headers {
+        let name = HeaderName::from_bytes(name.as_bytes()).ok()?;
+        let mut value = HeaderValue::from_str(value).ok()?;
+        value.set_sensitive(true);
+        default_headers.insert(name, value);
+    }
+    Client::builder()
+        .connect_timeout(Duration::from_secs(timeout_seconds))
+        .danger_accept_invalid_certs(insecure_tls)
+        .default_headers(default_headers)
+        .build()
+        .ok()
+}
+
 #[derive(Clone)]
 pub(crate) struct HttpRuntime {
     provider_id: String,
@@ -44,6 +64,7 @@ pub(crate) struct HttpRuntime {
     api_key: Option<String>,
     http_client: Option<Client>,
     max_inflight: Option<Arc<Semaphore>>,
+    max_response_bytes: Option<usize>,
 }
 
 impl HttpRuntime {
@@ -53,9 +74,10 @@ impl HttpRuntime {
         api_key: Option<String>,
         http_client: Option<Client>,
         max_inflight: Option<usize>,
+        max_response_bytes: Option<usize>,
     ) -> Self {
         let max_inflight = max_inflight.map(Semaphore::new).map(Arc::new);
-        Self { provider_id, base_url, api_key, http_client, max_inflight }
+        Self { provider_id, base_url, api_key, http_client, max_inflight, max_response_bytes }
     }
 
     pub(crate) fn api_key_ref(&self) -> Option<&str> {
@@ -87,8 +109,8 @@ impl HttpRuntime {
             .as_ref()
             .map(|semaphore| {
                 semaphore.clone().try_acquire_owned().map_err(|_| {
-                    CoreError::Provider(format!(
-                        "provider overloaded: max in-flight limit reached for {}",
+                    CoreError::ProviderOverloaded(format!(
+                        "max in-flight limit reached for {}",
                         self.provider_id
                     ))
                 })
@@ -96,6 +118,49 @@ impl HttpRuntime {
             .transpose()
     }
 
+    fn check_response_size(
+        &self,
+        request_id: &str,
+        received_bytes: usize,
+    ) -> Result<(), CoreError> {
+        match self.max_response_bytes {
+            Some(limit) if received_bytes > limit => {
+                warn!(
+                    event = "provider.response.too_large",
+                    provider = %self.provider_id,
+                    request_id = request_id,
+                    received_bytes = received_bytes,
+                    limit_bytes = limit
+                );
+                Err(CoreError::ResponseTooLarge(format!(
+                    "more than {limit} bytes from {}",
+                    self.provider_id
+                )))
+            }
+            _ => Ok(()),
+        }
+    }
+
+    async fn read_body(
+        &self,
+        request_id: &str,
+        response: reqwest::Response,
+    ) -> Result<Vec<u8>, CoreError> {
+        if let Some(length) = response.content_length() {
+            self.check_response_size(request_id, usize::try_from(length).unwrap_or(usize::MAX))?;
+        }
+        let mut body = Vec::new();
+        let mut stream = response.bytes_stream();
+        while let Some(next) = stream.next().await {
+            let bytes = next.map_err(|err| {
+                CoreError::Provider(format!("provider response read failed: {err}"))
+            })?;
+            body.extend_from_slice(&bytes);
+            self.check_response_size(request_id, body.len())?;
+        }
+        Ok(body)
+    }
+
     async fn send_post(
         &self,
         request_id: &str,
@@ -151,7 +216,11 @@ impl HttpRuntime {
                 return Ok(response);
             }
 
-            let body = response.text().await.unwrap_or_default();
+            let body = self
+                .read_body(request_id, response)
+                .await
+                .map(|body| String::from_utf8_lossy(&body).into_owned())
+                .unwrap_or_default();
             let body_preview = truncate_for_debug(
                 body.replace('\n', "\\n").replace('\r', "\\r").as_str(),
                 UPSTREAM_ERROR_BODY_PREVIEW_LIMIT,
@@ -192,9 +261,13 @@ impl HttpRuntime {
             http_span.set_status(Status::error(format!(
                 "provider returned error status: {status} ({reason})"
             )));
-            return Err(CoreError::Provider(format!(
-                "provider returned error status: {status} ({reason}) for url ({url})"
-            )));
+            return Err(CoreError::UpstreamStatus {
+                status: status.as_u16(),
+                message: format!(
+                    "provider returned error status: {status} ({reason}) for url ({url})"
+                ),
+                error: extract_openai_error_object(&body),
+            });
         }
 
         Err(CoreError::Provider(format!(
@@ -232,17 +305,19 @@ impl HttpRuntime {
             .is_some_and(|value| value.contains("application/json"));
 
         if is_json {
+            let body = self.read_body(request_id, response).await?;
             if self.provider_id == "gigachat" {
-                let payload = response.json::<Value>().await.map_err(|err| {
+                let payload = serde_json::from_slice::<Value>(&body).map_err(|err| {
                     CoreError::Provider(format!("provider response parse failed: {err}"))
                 })?;
                 return crate::clients::gigachat::map_gigachat_chat_completion_response_value(
                     &payload,
                 );
             }
-            let payload = response.json::<ChatCompletionsResponse>().await.map_err(|err| {
-                CoreError::Provider(format!("provider response parse failed: {err}"))
-            })?;
+            let payload =
+                serde_json::from_slice::<ChatCompletionsResponse>(&body).map_err(|err| {
+                    CoreError::Provider(format!("provider response parse failed: {err}"))
+                })?;
             return map_chat_completion_response(payload);
         }
 
@@ -252,10 +327,13 @@ impl HttpRuntime {
         let mut stream = response.bytes_stream();
         let mut transport_chunk_index = 0usize;
         let mut delta_count = 0usize;
+        let mut received_bytes = 0usize;
         while let Some(next) = stream.next().await {
             let bytes = next.map_err(|err| {
                 CoreError::Provider(format!("provider stream read failed: {err}"))
             })?;
+            received_bytes += bytes.len();
+            self.check_response_size(request_id, received_bytes)?;
             transport_chunk_index += 1;
             let chunk = String::from_utf8_lossy(&bytes).replace('\r', "");
             if should_log_stream_chunk_debug(transport_chunk_index) {
@@ -355,6 +433,7 @@ impl HttpRuntime {
                 ProviderOutcome {
                     chunks: all_chunks.clone(),
                     output_tokens,
+                    reasoning_tokens: 0,
                     reasoning: None,
                     reasoning_details: None,
                     tool_calls: None,
@@ -399,7 +478,8 @@ impl HttpRuntime {
             .is_some_and(|value| value.contains("application/json"));
 
         if is_json {
-            let payload = response.json::<ResponsesApiResponse>().await.map_err(|err| {
+            let body = self.read_body(request_id, response).await?;
+            let payload = serde_json::from_slice::<ResponsesApiResponse>(&body).map_err(|err| {
                 CoreError::Provider(format!("provider response parse failed: {err}"))
             })?;
             return map_responses_api_response(payload);
@@ -412,10 +492,13 @@ impl HttpRuntime {
         let mut stream = response.bytes_stream();
         let mut transport_chunk_index = 0usize;
         let mut delta_count = 0usize;
+        let mut received_bytes = 0usize;
         while let Some(next) = stream.next().await {
             let bytes = next.map_err(|err| {
                 CoreError::Provider(format!("provider stream read failed: {err}"))
             })?;
+            received_bytes += bytes.len();
+            self.check_response_size(request_id, received_bytes)?;
             transport_chunk_index += 1;
             let chunk = String::from_utf8_lossy(&bytes).replace('\r', "");
             if should_log_stream_chunk_debug(transport_chunk_index) {
@@ -501,6 +584,7 @@ impl HttpRuntime {
                 ProviderOutcome {
                     chunks: all_chunks.clone(),
                     output_tokens,
+                    reasoning_tokens: 0,
                     reasoning: None,
                     reasoning_details: None,
                     tool_calls: None,
@@ -695,15 +779,25 @@ pub(crate) fn should_retry_failed_status(
         && body.to_ascii_lowercase().contains("operation failed")
 }
 
+fn extract_openai_error_object(body: &str) -> Option<Value> {
+    let parsed = serde_json::from_str::<Value>(body).ok()?;
+    let error = parsed.get("error")?;
+    error.get("message")?.as_str()?;
+    Some(error.clone())
+}
+
 #[cfg(test)]
 mod tests {
-    use super::{inject_trace_headers, should_retry_failed_status};
+    use super::{
+        HttpRuntime, extract_openai_error_object, inject_trace_headers, should_retry_failed_status,
+    };
     use opentelemetry::{
         global,
         propagation::{Extractor, TextMapPropagator},
         trace::{TraceContextExt, TracerProvider},
     };
     use opentelemetry_sdk::{propagation::TraceContextPropagator, trace::SdkTracerProvider};
+    use serde_json::Value;
     use tracing::trace_span;
     use tracing_opentelemetry::OpenTelemetrySpanExt;
     use tracing_subscriber::layer::SubscriberExt;
@@ -782,6 +876,22 @@ mod tests {
         ));
     }
 
+    #[test]
+    fn check_response_size_rejects_bodies_over_the_limit() {
+        let limited = HttpRuntime::new("deepseek".to_string(), None, None, None, None, Some(1_024));
+        assert!(limited.check_response_size("req_1", 1_024).is_ok());
+        let err = limited
+            .check_response_size("req_1", 1_025)
+            .expect_err("body over the limit must be rejected");
+        assert_eq!(
+            err.to_string(),
+            "provider error: provider response too large: more than 1024 bytes from deepseek"
+        );
+
+        let unlimited = HttpRuntime::new("deepseek".to_string(), None, None, None, None, None);
+        assert!(unlimited.check_response_size("req_1", usize::MAX).is_ok());
+    }
+
     struct HeaderMapExtractor<'a>(&'a reqwest::header::HeaderMap);
 
     impl<'a> Extractor for HeaderMapExtractor<'a> {
@@ -793,4 +903,16 @@ mod tests {
             self.0.keys().map(reqwest::header::HeaderName::as_str).collect()
         }
     }
+
+    #[test]
+    fn extracts_upstream_error_object_only_for_openai_schema() {
+        let error = extract_openai_error_object(
+            "{\"error\":{\"message\":\"bad key\",\"type\":\"invalid_request_error\",\"code\":\"invalid_api_key\"}}",
+        )
+        .expect("openai-shaped error must be extracted");
+        assert_eq!(error.get("code").and_then(Value::as_str), Some("invalid_api_key"));
+
+        assert!(extract_openai_error_object("{\"error\":\"bad key\"}").is_none());
+        assert!(extract_openai_error_object("<html>bad gateway</html>").is_none());
+    }
 }
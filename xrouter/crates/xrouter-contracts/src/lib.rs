@@ -220,6 +220,13 @@ pub struct Usage {
     pub input_tokens: u32,
     pub output_tokens: u32,
     pub total_tokens: u32,
+    #[serde(default)]
+    pub output_tokens_details: OutputTokensDetails,
+}
+
+#[derive(Debug, Clone, Default, Deserialize, Serialize, PartialEq, Eq, ToSchema)]
+pub struct OutputTokensDetails {
+    pub reasoning_tokens: u32,
 }
 
 #[derive(Debug, Clone, Deserialize, Serialize, PartialEq, Eq, ToSchema)]
@@ -315,6 +322,8 @@ pub struct ChatMessage {
     pub reasoning_details: Option<Vec<Value>>,
     #[serde(skip_serializing_if = "Option::is_none")]
     pub tool_calls: Option<Vec<ToolCall>>,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub tool_call_id: Option<String>,
 }
 
 #[derive(Debug, Clone, Deserialize, Serialize, PartialEq, Eq, ToSchema)]
@@ -343,19 +352,32 @@ pub struct ChatCompletionsResponse {
 }
 
 impl ChatCompletionsRequest {
-    pub fn into_responses_request(self) -> ResponsesRequest {
-        let input = self
-            .messages
-            .into_iter()
-            .map(|m| format!("{}:{}", m.role, m.content))
-            .collect::<Vec<_>>()
-            .join("\n");
-
-        ResponsesRequest {
+    // Messages are flattened into one "role:content" text input. A conversation that ends with an
+    // assistant message (prefill) keeps one input item per message instead, so the trailing
+    // assistant turn reaches the provider as such; the error describes a message that has no
+    // item form.
+    pub fn into_responses_request(self) -> Result<ResponsesRequest, String> {
+        let input = if self.messages.last().is_some_and(|message| message.role == "assistant") {
+            let mut items = Vec::with_capacity(self.messages.len());
+            for message in self.messages {
+                push_chat_message_items(&mut items, message)?;
+            }
+            ResponsesInput::Items(items)
+        } else {
+            ResponsesInput::Text(
+                self.messages
+                    .into_iter()
+                    .map(|m| format!("{}:{}", m.role, m.content))
+                    .collect::<Vec<_>>()
+                    .join("\n"),
+            )
+        };
+
+        Ok(ResponsesRequest {
             model: self.model,
             instructions: None,
             previous_response_id: None,
-            input: ResponsesInput::Text(input),
+            input,
             parallel_tool_calls: None,
             stream: self.stream,
             reasoning: self.reasoning,
@@ -366,8 +388,48 @@ impl ChatCompletionsRequest {
             text: None,
             tools: None,
             tool_choice: None,
+        })
+    }
+}
+
+fn push_chat_message_items(
+    items: &mut Vec<ResponseInputItem>,
+    message: ChatMessage,
+) -> Result<(), String> {
+    match message.role.as_str() {
+        "system" | "developer" | "user" | "assistant" => {
+            let tool_calls = message.tool_calls.unwrap_or_default();
+            if !message.content.is_empty() || tool_calls.is_empty() {
+                items.push(ResponseInputItem {
+                    kind: Some("message".to_string()),
+                    role: Some(message.role),
+                    content: Some(ResponseInputContent::Text(message.content)),
+                    ..Default::default()
+                });
+            }
+            items.extend(tool_calls.into_iter().map(|call| ResponseInputItem {
+                kind: Some("function_call".to_string()),
+                call_id: Some(call.id),
+                name: Some(call.function.name),
+                arguments: Some(call.function.arguments),
+                ..Default::default()
+            }));
+        }
+        "tool" => {
+            let call_id = message
+                .tool_call_id
+                .filter(|call_id| !call_id.trim().is_empty())
+                .ok_or_else(|| "tool message must have a tool_call_id".to_string())?;
+            items.push(ResponseInputItem {
+                kind: Some("function_call_output".to_string()),
+                call_id: Some(call_id),
+                output: Some(ResponseToolOutput::Text(message.content)),
+                ..Default::default()
+            });
         }
+        other => return Err(format!("unsupported chat message role: {other}")),
     }
+    Ok(())
 }
 
 impl ChatCompletionsResponse {
@@ -414,6 +476,7 @@ impl ChatCompletionsResponse {
                     reasoning_content: reasoning,
                     reasoning_details,
                     tool_calls: if tool_calls.is_empty() { None } else { Some(tool_calls) },
+                    tool_call_id: None,
                 },
                 finish_reason: response.finish_reason,
             }],
@@ -735,4 +798,74 @@ mod tests {
             "user:hello\nassistant:working on it\nassistant_reasoning:checked workspace\nassistant_function_call:list_dir:{\"dir_path\":\"/workspace\"}\ntool:call_1:Absolute path: /workspace\ntool:call_2:patch applied"
         );
     }
+
+    fn chat_request(messages: &str) -> ChatCompletionsRequest {
+        serde_json::from_str(&format!(r#"{{"model":"m","messages":{messages}}}"#))
+            .expect("request must deserialize")
+    }
+
+    #[test]
+    fn chat_request_without_prefill_keeps_text_input() {
+        let converted = chat_request(
+            r#"[{"role":"developer","content":"be brief"},{"role":"user","content":"hi"}]"#,
+        )
+        .into_responses_request()
+        .expect("request must convert");
+
+        assert_eq!(
+            converted.input,
+            ResponsesInput::Text("developer:be brief\nuser:hi".to_string())
+        );
+    }
+
+    #[test]
+    fn chat_request_with_prefill_keeps_message_roles_and_tool_calls_as_items() {
+        let converted = chat_request(
+            r#"[
+                {"role":"developer","content":"be brief"},
+                {"role":"user","content":"hi"},
+                {"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},
+                {"role":"tool","tool_call_id":"call_1","content":"42"},
+                {"role":"assistant","content":"Sure,"}
+            ]"#,
+        )
+        .into_responses_request()
+        .expect("request must convert");
+
+        let ResponsesInput::Items(items) = &converted.input else {
+            panic!("prefill requests must convert to input items");
+        };
+        let kinds = items.iter().map(|item| item.kind.as_deref()).collect::<Vec<_>>();
+        assert_eq!(
+            kinds,
+            [
+                Some("message"),
+                Some("message"),
+                Some("function_call"),
+                Some("function_call_output"),
+                Some("message")
+            ]
+        );
+        assert_eq!(
+            converted.input.to_canonical_text(),
+            "developer:be brief\nuser:hi\nassistant_function_call:lookup:{}\ntool:call_1:42\nassistant:Sure,"
+        );
+    }
+
+    #[test]
+    fn chat_request_with_prefill_rejects_messages_without_item_form() {
+        let error = chat_request(
+            r#"[{"role":"tool","content":"42"},{"role":"assistant","content":"Sure,"}]"#,
+        )
+        .into_responses_request()
+        .expect_err("tool message without id must be rejected");
+        assert_eq!(error, "tool message must have a tool_call_id");
+
+        let error = chat_request(
+            r#"[{"role":"function","content":"42"},{"role":"assistant","content":"Sure,"}]"#,
+        )
+        .into_responses_request()
+        .expect_err("unknown role must be rejected");
+        assert_eq!(error, "unsupported chat message role: function");
+    }
 }
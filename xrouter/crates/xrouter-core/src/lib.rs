@@ -4,7 +4,7 @@ use async_trait::async_trait;
 use tracing::{Instrument, error, field, info, info_span, warn};
 use uuid::Uuid;
 use xrouter_contracts::{
-    ReasoningConfig, ResponseEvent, ResponseOutputItem, ResponseOutputText,
+    OutputTokensDetails, ReasoningConfig, ResponseEvent, ResponseOutputItem, ResponseOutputText,
     ResponseReasoningSummary, ResponsesInput, ResponsesRequest, ResponsesResponse, StageName,
     ToolCall, ToolFunction, Usage,
 };
@@ -15,6 +15,14 @@ pub enum CoreError {
     Validation(String),
     #[error("provider error: {0}")]
     Provider(String),
+    #[error("provider error: {message}")]
+    UpstreamStatus { status: u16, message: String, error: Option<serde_json::Value> },
+    #[error("provider error: provider overloaded: {0}")]
+    ProviderOverloaded(String),
+    #[error("provider error: provider response too large: {0}")]
+    ResponseTooLarge(String),
+    #[error("provider error: provider circuit open: {model}; retry in {retry_after_seconds}s")]
+    CircuitOpen { model: String, retry_after_seconds: u64 },
     #[error("client disconnected during {0:?}")]
     ClientDisconnected(StageName),
 }
@@ -50,18 +58,20 @@ pub struct ExecutionContext {
     pub reasoning_details: Option<Vec<serde_json::Value>>,
     pub input_tokens: u32,
     pub output_tokens: u32,
+    pub reasoning_tokens: u32,
 }
 
 impl ExecutionContext {
     fn new(
         request: ResponsesRequest,
+        request_id: Option<String>,
         auth_bearer: Option<String>,
         forward_headers: Vec<(String, String)>,
     ) -> Self {
         let request_input = request.input.clone();
         let input = request_input.to_canonical_text();
         Self {
-            request_id: Uuid::new_v4().to_string(),
+            request_id: request_id.unwrap_or_else(|| Uuid::new_v4().to_string()),
             state: KernelState::Ingest,
             client_connected: true,
             response_completed: false,
@@ -80,6 +90,7 @@ impl ExecutionContext {
             reasoning_details: None,
             input_tokens: 0,
             output_tokens: 0,
+            reasoning_tokens: 0,
         }
     }
 }
@@ -88,6 +99,7 @@ impl ExecutionContext {
 pub struct ProviderOutcome {
     pub chunks: Vec<String>,
     pub output_tokens: u32,
+    pub reasoning_tokens: u32,
     pub reasoning: Option<String>,
     pub reasoning_details: Option<Vec<serde_json::Value>>,
     pub tool_calls: Option<Vec<ToolCall>>,
@@ -379,6 +391,10 @@ pub trait ProviderClient: Send + Sync {
         let _ = request.sender;
         self.generate(request.request).await
     }
+
+    fn supports_assistant_prefill(&self) -> bool {
+        false
+    }
 }
 
 #[cfg_attr(target_arch = "wasm32", async_trait(?Send))]
@@ -423,9 +439,7 @@ impl StageHandler for IngestHandler {
     }
 
     async fn handle(&self, context: &mut ExecutionContext) -> Result<(), CoreError> {
-        if context.input.trim().is_empty() {
-            return Err(CoreError::Validation("input must not be empty".to_string()));
-        }
+        validate_ingest_input(&context.input)?;
         context.state = KernelState::Tokenize;
         Ok(())
     }
@@ -441,12 +455,23 @@ impl StageHandler for TokenizeHandler {
     }
 
     async fn handle(&self, context: &mut ExecutionContext) -> Result<(), CoreError> {
-        context.input_tokens = context.input.split_whitespace().count() as u32;
+        context.input_tokens = estimate_input_tokens(&context.input);
         context.state = KernelState::Generate;
         Ok(())
     }
 }
 
+fn validate_ingest_input(input: &str) -> Result<(), CoreError> {
+    if input.trim().is_empty() {
+        return Err(CoreError::Validation("input must not be empty".to_string()));
+    }
+    Ok(())
+}
+
+fn estimate_input_tokens(input: &str) -> u32 {
+    input.split_whitespace().count() as u32
+}
+
 struct GenerateHandler {
     provider: Arc<dyn ProviderClient>,
     sender: Option<Arc<dyn ResponseEventSink>>,
@@ -481,6 +506,7 @@ impl StageHandler for GenerateHandler {
             xrouter.model_id = %context.model,
             input.value = %truncate_text(&context.input, 512),
             output_tokens = field::Empty,
+            reasoning_tokens = field::Empty,
             chunk_count = field::Empty,
             output.value = field::Empty,
             token_count.prompt = field::Empty,
@@ -522,6 +548,7 @@ impl StageHandler for GenerateHandler {
             }
         };
         provider_span.record("output_tokens", result.output_tokens);
+        provider_span.record("reasoning_tokens", result.reasoning_tokens);
         provider_span.record("chunk_count", result.chunks.len());
         provider_span.record("output.value", truncate_text(&result.chunks.join(""), 512));
         provider_span.record("token_count.prompt", context.input_tokens);
@@ -534,11 +561,13 @@ impl StageHandler for GenerateHandler {
             event = "provider.request.completed",
             provider_model = %context.model,
             output_tokens = result.output_tokens,
+            reasoning_tokens = result.reasoning_tokens,
             chunk_count = result.chunks.len(),
             duration_ms = provider_started_at.elapsed().as_millis() as u64
         );
 
         context.output_tokens = result.output_tokens;
+        context.reasoning_tokens = result.reasoning_tokens;
         context.tool_calls = result.tool_calls;
         context.reasoning = result.reasoning;
         context.reasoning_details = result.reasoning_details;
@@ -578,6 +607,17 @@ pub struct ExecutionEngine {
     provider: Arc<dyn ProviderClient>,
 }
 
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct PreflightReport {
+    pub input_tokens: u32,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct PreflightError {
+    pub stage: StageName,
+    pub error: CoreError,
+}
+
 fn tool_call_id_from_response_id(response_id: &str) -> String {
     let suffix = response_id.strip_prefix("resp_").unwrap_or(response_id);
     format!("call_{suffix}")
@@ -675,6 +715,9 @@ pub fn responses_response_from_outcome(
             input_tokens,
             output_tokens: outcome.output_tokens,
             total_tokens: input_tokens + outcome.output_tokens,
+            output_tokens_details: OutputTokensDetails {
+                reasoning_tokens: outcome.reasoning_tokens,
+            },
         },
     }
 }
@@ -698,17 +741,29 @@ impl ExecutionEngine {
         Self { provider }
     }
 
+    pub fn supports_assistant_prefill(&self) -> bool {
+        self.provider.supports_assistant_prefill()
+    }
+
+    pub fn preflight(&self, request: &ResponsesRequest) -> Result<PreflightReport, PreflightError> {
+        let input = request.input.to_canonical_text();
+        validate_ingest_input(&input)
+            .map_err(|error| PreflightError { stage: StageName::Ingest, error })?;
+        Ok(PreflightReport { input_tokens: estimate_input_tokens(&input) })
+    }
+
     pub async fn execute(&self, request: ResponsesRequest) -> Result<ResponsesResponse, CoreError> {
-        self.execute_with_auth(request, None, Vec::new()).await
+        self.execute_with_auth(request, None, None, Vec::new()).await
     }
 
     pub async fn execute_with_auth(
         &self,
         request: ResponsesRequest,
+        request_id: Option<String>,
         auth_bearer: Option<String>,
         forward_headers: Vec<(String, String)>,
     ) -> Result<ResponsesResponse, CoreError> {
-        self.execute_internal(request, None, None, auth_bearer, forward_headers).await
+        self.execute_internal(request, request_id, None, None, auth_bearer, forward_headers).await
     }
 
     pub async fn execute_with_disconnect(
@@ -716,12 +771,13 @@ impl ExecutionEngine {
         request: ResponsesRequest,
         disconnect_at: Option<StageName>,
     ) -> Result<ResponsesResponse, CoreError> {
-        self.execute_internal(request, disconnect_at, None, None, Vec::new()).await
+        self.execute_internal(request, None, disconnect_at, None, None, Vec::new()).await
     }
 
     pub async fn execute_stream_to_sink(
         &self,
         request: ResponsesRequest,
+        request_id: Option<String>,
         disconnect_at: Option<StageName>,
         auth_bearer: Option<String>,
         forward_headers: Vec<(String, String)>,
@@ -737,6 +793,7 @@ impl ExecutionEngine {
         let result = self
             .execute_internal(
                 request,
+                request_id,
                 disconnect_at,
                 Some(sender.clone()),
                 auth_bearer,
@@ -745,12 +802,7 @@ impl ExecutionEngine {
             .instrument(execute_stream_span)
             .await;
         if let Err(error) = &result {
-            sender
-                .send(Ok(ResponseEvent::ResponseError {
-                    id: "unknown".to_string(),
-                    message: error.to_string(),
-                }))
-                .await;
+            sender.send(Err(error.clone())).await;
         }
         result.map(|_| ())
     }
@@ -758,13 +810,14 @@ impl ExecutionEngine {
     async fn execute_internal(
         &self,
         request: ResponsesRequest,
+        request_id: Option<String>,
         disconnect_at: Option<StageName>,
         sender: Option<Arc<dyn ResponseEventSink>>,
         auth_bearer: Option<String>,
         forward_headers: Vec<(String, String)>,
     ) -> Result<ResponsesResponse, CoreError> {
         let request_started_at = Instant::now();
-        let mut context = ExecutionContext::new(request, auth_bearer, forward_headers);
+        let mut context = ExecutionContext::new(request, request_id, auth_bearer, forward_headers);
         info!(
             event = "core.request.started",
             request_id = %context.request_id,
@@ -834,6 +887,7 @@ impl ExecutionEngine {
         let terminal_outcome = ProviderOutcome {
             chunks: vec![context.output_text.clone()],
             output_tokens: context.output_tokens,
+            reasoning_tokens: context.reasoning_tokens,
             reasoning: context.reasoning.clone(),
             reasoning_details: context.reasoning_details.clone(),
             tool_calls: tool_calls.clone(),
@@ -862,6 +916,7 @@ impl ExecutionEngine {
             finish_reason = %finish_reason,
             input_tokens = response.usage.input_tokens,
             output_tokens = response.usage.output_tokens,
+            reasoning_tokens = response.usage.output_tokens_details.reasoning_tokens,
             total_tokens = response.usage.total_tokens,
             output_items = response.output.len(),
             duration_ms = request_started_at.elapsed().as_millis() as u64
@@ -1014,6 +1069,7 @@ mod tests {
                     let chunks = vec!["hello ".to_string(), input_text];
                     Ok(ProviderOutcome {
                         output_tokens: 2,
+                        reasoning_tokens: 0,
                         chunks,
                         reasoning: None,
                         reasoning_details: None,
@@ -1062,6 +1118,10 @@ mod tests {
         match error {
             CoreError::Validation(_) => "Validation",
             CoreError::Provider(_) => "Provider",
+            CoreError::UpstreamStatus { .. } => "UpstreamStatus",
+            CoreError::ProviderOverloaded(_) => "ProviderOverloaded",
+            CoreError::ResponseTooLarge(_) => "ResponseTooLarge",
+            CoreError::CircuitOpen { .. } => "CircuitOpen",
             CoreError::ClientDisconnected(_) => "ClientDisconnected",
         }
     }
@@ -1223,6 +1283,7 @@ usage_total=3
             Ok(ProviderOutcome {
                 chunks: vec!["ok".to_string()],
                 output_tokens: 1,
+                reasoning_tokens: 0,
                 reasoning: None,
                 reasoning_details: None,
                 tool_calls: None,
@@ -1231,6 +1292,39 @@ usage_total=3
         }
     }
 
+    #[test]
+    fn preflight_reports_tokens_without_calling_provider() {
+        let engine =
+            ExecutionEngine::new(Arc::new(FakeProvider { behavior: ProviderBehavior::Fail }));
+        let mut request = ResponsesRequest {
+            model: "fake".to_string(),
+            instructions: None,
+            previous_response_id: None,
+            input: xrouter_contracts::ResponsesInput::Text("hello brave world".to_string()),
+            parallel_tool_calls: None,
+            stream: false,
+            reasoning: None,
+            store: None,
+            include: None,
+            service_tier: None,
+            prompt_cache_key: None,
+            text: None,
+            tools: None,
+            tool_choice: None,
+        };
+
+        assert_eq!(engine.preflight(&request), Ok(PreflightReport { input_tokens: 3 }));
+
+        request.input = xrouter_contracts::ResponsesInput::Text("   ".to_string());
+        assert_eq!(
+            engine.preflight(&request),
+            Err(PreflightError {
+                stage: StageName::Ingest,
+                error: CoreError::Validation("input must not be empty".to_string()),
+            })
+        );
+    }
+
     #[tokio::test]
     async fn execute_with_auth_passes_bearer_to_provider() {
         let seen = Arc::new(Mutex::new(None));
@@ -1258,7 +1352,7 @@ usage_total=3
         };
 
         let _ = engine
-            .execute_with_auth(request, Some("byok-test-token".to_string()), Vec::new())
+            .execute_with_auth(request, None, Some("byok-test-token".to_string()), Vec::new())
             .await
             .expect("request must succeed");
 
@@ -1330,7 +1424,7 @@ usage_total=3
         ];
 
         let _ = engine
-            .execute_with_auth(request, None, forward_headers.clone())
+            .execute_with_auth(request, None, None, forward_headers.clone())
             .await
             .expect("request must succeed");
 
@@ -1361,7 +1455,7 @@ usage_total=3
         };
 
         engine
-            .execute_stream_to_sink(request, None, None, Vec::new(), sink)
+            .execute_stream_to_sink(request, None, None, None, Vec::new(), sink)
             .await
             .expect("stream request must succeed");
 
@@ -1393,13 +1487,12 @@ usage_total=3
             tool_choice: None,
         };
 
-        let result = engine.execute_stream_to_sink(request, None, None, Vec::new(), sink).await;
+        let result =
+            engine.execute_stream_to_sink(request, None, None, None, Vec::new(), sink).await;
 
         assert!(matches!(result, Err(CoreError::Provider(_))));
         let events = events.lock().expect("lock must succeed");
-        assert!(
-            events.iter().any(|event| matches!(event, Ok(ResponseEvent::ResponseError { .. })))
-        );
+        assert!(events.iter().any(|event| matches!(event, Err(CoreError::Provider(_)))));
     }
 
     #[tokio::test]
@@ -1425,13 +1518,16 @@ usage_total=3
         };
 
         let result = engine
-            .execute_stream_to_sink(request, Some(StageName::Ingest), None, Vec::new(), sink)
+            .execute_stream_to_sink(request, None, Some(StageName::Ingest), None, Vec::new(), sink)
             .await;
 
         assert_eq!(result, Err(CoreError::ClientDisconnected(StageName::Ingest)));
         let events = events.lock().expect("lock must succeed");
         assert!(
-            events.iter().any(|event| matches!(event, Ok(ResponseEvent::ResponseError { .. }))),
+            events.iter().any(|event| matches!(
+                event,
+                Err(CoreError::ClientDisconnected(StageName::Ingest))
+            )),
             "ingest disconnect must surface as stream error event"
         );
         assert!(
@@ -1465,7 +1561,14 @@ usage_total=3
         };
 
         engine
-            .execute_stream_to_sink(request, Some(StageName::Generate), None, Vec::new(), sink)
+            .execute_stream_to_sink(
+                request,
+                None,
+                Some(StageName::Generate),
+                None,
+                Vec::new(),
+                sink,
+            )
             .await
             .expect("generate disconnect must not cancel in-flight generation");
 
@@ -1480,11 +1583,110 @@ usage_total=3
         );
     }
 
+    #[tokio::test]
+    async fn execute_with_auth_uses_caller_request_id() {
+        let engine = ExecutionEngine::new(build_provider(ProviderBehavior::Success));
+        let request = ResponsesRequest {
+            model: "fake".to_string(),
+            instructions: None,
+            previous_response_id: None,
+            input: xrouter_contracts::ResponsesInput::Text("hello".to_string()),
+            parallel_tool_calls: None,
+            stream: false,
+            reasoning: None,
+            store: None,
+            include: None,
+            service_tier: None,
+            prompt_cache_key: None,
+            text: None,
+            tools: None,
+            tool_choice: None,
+        };
+
+        let response = engine
+            .execute_with_auth(request, Some("req-from-caller".to_string()), None, Vec::new())
+            .await
+            .expect("request must succeed");
+
+        assert_eq!(response.id, "req-from-caller");
+    }
+
+    struct ReasoningProvider;
+
+    #[cfg_attr(target_arch = "wasm32", async_trait(?Send))]
+    #[cfg_attr(not(target_arch = "wasm32"), async_trait)]
+    impl ProviderClient for ReasoningProvider {
+        async fn generate(
+            &self,
+            _request: ProviderGenerateRequest<'_>,
+        ) -> Result<ProviderOutcome, CoreError> {
+            Ok(ProviderOutcome {
+                chunks: vec!["4".to_string()],
+                output_tokens: 6,
+                reasoning_tokens: 5,
+                reasoning: Some("2+2=4".to_string()),
+                reasoning_details: None,
+                tool_calls: None,
+                emitted_live: false,
+            })
+        }
+    }
+
+    #[tokio::test]
+    async fn terminal_outcome_carries_accumulated_reasoning_tokens() {
+        let engine = ExecutionEngine::new(Arc::new(ReasoningProvider));
+        let request = ResponsesRequest {
+            model: "fake".to_string(),
+            instructions: None,
+            previous_response_id: None,
+            input: xrouter_contracts::ResponsesInput::Text("2+2?".to_string()),
+            parallel_tool_calls: None,
+            stream: false,
+            reasoning: None,
+            store: None,
+            include: None,
+            service_tier: None,
+            prompt_cache_key: None,
+            text: None,
+            tools: None,
+            tool_choice: None,
+        };
+
+        let response = engine
+            .execute_with_auth(request.clone(), None, None, Vec::new())
+            .await
+            .expect("execution must succeed");
+        assert_eq!(response.usage.output_tokens_details.reasoning_tokens, 5);
+
+        let events = Arc::new(Mutex::new(Vec::new()));
+        let sink = Arc::new(CaptureSink { events: events.clone() });
+        engine
+            .execute_stream_to_sink(
+                ResponsesRequest { stream: true, ..request },
+                None,
+                None,
+                None,
+                Vec::new(),
+                sink,
+            )
+            .await
+            .expect("stream execution must succeed");
+        let events = events.lock().expect("lock must succeed");
+        let streamed_reasoning_tokens = events.iter().find_map(|event| match event {
+            Ok(ResponseEvent::ResponseCompleted { usage, .. }) => {
+                Some(usage.output_tokens_details.reasoning_tokens)
+            }
+            _ => None,
+        });
+        assert_eq!(streamed_reasoning_tokens, Some(5));
+    }
+
     #[test]
     fn responses_response_from_outcome_preserves_function_calls() {
         let outcome = ProviderOutcome {
             chunks: vec![String::new()],
             output_tokens: 3,
+            reasoning_tokens: 2,
             reasoning: None,
             reasoning_details: None,
             tool_calls: Some(vec![ToolCall {
@@ -1506,5 +1708,6 @@ usage_total=3
             if call_id == "call_123" && name == "lookup_weather"
         )));
         assert_eq!(response.usage.total_tokens, 8);
+        assert_eq!(response.usage.output_tokens_details.reasoning_tokens, 2);
     }
 }
@@ -123,8 +123,8 @@ if [[ "$auth_mode" == "byok" && "$provider" != "yandex" ]]; then
     -X POST "$RESPONSES_URL" \
     -H "Content-Type: application/json" \
     -d "$byok_probe_payload")
-  if [[ "$byok_probe_code" -ne 400 ]]; then
-    echo "expected strict BYOK behavior (missing Authorization -> 400), got http=$byok_probe_code" >&2
+  if [[ "$byok_probe_code" -ne 401 ]]; then
+    echo "expected strict BYOK behavior (missing Authorization -> 401), got http=$byok_probe_code" >&2
     cat /tmp/xrouter_smoke_probe_body.json >&2 || true
     echo "ensure server is started with XR_BYOK_ENABLED=true" >&2
     exit 1